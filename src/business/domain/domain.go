@@ -6,10 +6,14 @@ import (
 	"github.com/downsized-devs/sdk-go/logger"
 	"github.com/downsized-devs/sdk-go/parser"
 	"github.com/downsized-devs/sdk-go/sql"
+	"github.com/downsized-devs/template-service-go/src/business/domain/permission"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/health"
 )
 
 type Domains struct {
 	// Add domain package interfaces here
+	Permission permission.Interface
 }
 
 type InitParam struct {
@@ -17,10 +21,14 @@ type InitParam struct {
 	Db     sql.Interface
 	Parser parser.Parser
 	Http   *http.Client
+	Conf   config.BusinessConfig
+	Health health.Interface
 }
 
 func Init(param InitParam) *Domains {
-	dom := &Domains{}
+	dom := &Domains{
+		Permission: permission.Init(param.Log, param.Db, param.Conf.Permission, param.Health),
+	}
 
 	return dom
 }
@@ -0,0 +1,147 @@
+package permission
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/downsized-devs/sdk-go/sql"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/health"
+)
+
+const (
+	SourceConfig string = "config"
+	SourceSQL    string = "sql"
+
+	defaultTable    string = "role_permission"
+	healthCheckName string = "permission"
+)
+
+type Interface interface {
+	GetByRoleID(ctx context.Context, roleID int64) ([]entity.Permission, error)
+}
+
+type cachedPermission struct {
+	permissions []entity.Permission
+	expiredAt   time.Time
+}
+
+type permission struct {
+	log   logger.Interface
+	db    sql.Interface
+	conf  config.PermissionConfig
+	mu    sync.RWMutex
+	cache map[int64]cachedPermission
+}
+
+func Init(log logger.Interface, db sql.Interface, conf config.PermissionConfig, hc health.Interface) Interface {
+	if conf.Table == "" {
+		conf.Table = defaultTable
+	}
+
+	p := &permission{
+		log:   log,
+		db:    db,
+		conf:  conf,
+		cache: map[int64]cachedPermission{},
+	}
+
+	if conf.Source == SourceSQL {
+		hc.Register(healthCheckName, p.healthCheck)
+	}
+
+	return p
+}
+
+// healthCheck makes sure the permission table is reachable, not only the database
+func (p *permission) healthCheck(ctx context.Context) error {
+	rows, err := p.db.Follower().Query(ctx, "rPermissionHealth", fmt.Sprintf(readPermissionHealth, p.conf.Table))
+	if err != nil {
+		return errors.NewWithCode(codes.CodeSQLRead, "%s", err.Error())
+	}
+
+	return rows.Close()
+}
+
+func (p *permission) GetByRoleID(ctx context.Context, roleID int64) ([]entity.Permission, error) {
+	switch p.conf.Source {
+	case SourceSQL:
+		return p.getSQLCached(ctx, roleID)
+	case SourceConfig, "":
+		return p.getConfig(roleID), nil
+	default:
+		return nil, errors.NewWithCode(codes.CodeNotImplemented, "unknown permission source %s", p.conf.Source)
+	}
+}
+
+func (p *permission) getConfig(roleID int64) []entity.Permission {
+	permissions := []entity.Permission{}
+	for _, actionCode := range p.conf.Roles[strconv.FormatInt(roleID, 10)] {
+		permissions = append(permissions, entity.Permission{
+			RoleID:     roleID,
+			ActionCode: actionCode,
+			Resource:   entity.ResourceAll,
+		})
+	}
+
+	return permissions
+}
+
+func (p *permission) getSQLCached(ctx context.Context, roleID int64) ([]entity.Permission, error) {
+	p.mu.RLock()
+	cached, ok := p.cache[roleID]
+	p.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiredAt) {
+		return cached.permissions, nil
+	}
+
+	permissions, err := p.getSQL(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.conf.CacheTTL > 0 {
+		p.mu.Lock()
+		p.cache[roleID] = cachedPermission{
+			permissions: permissions,
+			expiredAt:   time.Now().Add(p.conf.CacheTTL),
+		}
+		p.mu.Unlock()
+	}
+
+	return permissions, nil
+}
+
+func (p *permission) getSQL(ctx context.Context, roleID int64) ([]entity.Permission, error) {
+	permissions := []entity.Permission{}
+
+	query := p.db.Follower().Rebind(fmt.Sprintf(readPermissionByRoleID, p.conf.Table))
+	rows, err := p.db.Follower().Query(ctx, "rPermissionByRoleID", query, roleID)
+	if err != nil {
+		return permissions, errors.NewWithCode(codes.CodeSQLRead, "%s", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		perm := entity.Permission{}
+		// a skipped row would drop a grant of the role, fail the lookup instead
+		if err := rows.StructScan(&perm); err != nil {
+			return permissions, errors.NewWithCode(codes.CodeSQLRowScan, "%s", err.Error())
+		}
+		permissions = append(permissions, perm)
+	}
+
+	// a connection lost half way ends the rows early, the role must not look less privileged than it is
+	if err := rows.Err(); err != nil {
+		return permissions, errors.NewWithCode(codes.CodeSQLRead, "%s", err.Error())
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,166 @@
+package permission
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	mock_sql "github.com/downsized-devs/sdk-go/tests/mock/sql"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/health"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/mock/gomock"
+)
+
+// newRows returns the rows a permission query would read from the table
+func newRows(t *testing.T, rows *sqlmock.Rows) *sqlx.Rows {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+	got, err := sqlx.NewDb(db, "sqlmock").Queryx("SELECT")
+	if err != nil {
+		t.Fatalf("Queryx() error = %v", err)
+	}
+
+	return got
+}
+
+func Test_permission_GetByRoleID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	dbMock := mock_sql.NewMockInterface(ctrl)
+	followerMock := mock_sql.NewMockCommand(ctrl)
+
+	columns := []string{"id", "fk_role_id", "action_code", "resource"}
+
+	type mockFields struct {
+		log      *mock_log.MockInterface
+		follower *mock_sql.MockCommand
+	}
+
+	mocks := mockFields{
+		log:      logMock,
+		follower: followerMock,
+	}
+
+	tests := []struct {
+		name     string
+		conf     config.PermissionConfig
+		mockFunc func(m mockFields)
+		want     []entity.Permission
+		wantErr  bool
+	}{
+		{
+			name:     "config",
+			conf:     config.PermissionConfig{Roles: map[string][]string{"1": {entity.ActionCodeSchedulerTrigger}}},
+			mockFunc: func(m mockFields) {},
+			want:     []entity.Permission{{RoleID: 1, ActionCode: entity.ActionCodeSchedulerTrigger, Resource: entity.ResourceAll}},
+		},
+		{
+			name:     "config without the role",
+			conf:     config.PermissionConfig{Source: SourceConfig, Roles: map[string][]string{"2": {entity.ActionCodeAll}}},
+			mockFunc: func(m mockFields) {},
+			want:     []entity.Permission{},
+		},
+		{
+			name: "sql",
+			conf: config.PermissionConfig{Source: SourceSQL},
+			mockFunc: func(m mockFields) {
+				rows := sqlmock.NewRows(columns).AddRow(7, 1, entity.ActionCodeSchedulerTrigger, "")
+				m.follower.EXPECT().Query(gomock.Any(), "rPermissionByRoleID", gomock.Any(), int64(1)).Return(newRows(t, rows), nil)
+			},
+			want: []entity.Permission{{ID: 7, RoleID: 1, ActionCode: entity.ActionCodeSchedulerTrigger}},
+		},
+		{
+			name: "sql error",
+			conf: config.PermissionConfig{Source: SourceSQL},
+			mockFunc: func(m mockFields) {
+				m.follower.EXPECT().Query(gomock.Any(), "rPermissionByRoleID", gomock.Any(), int64(1)).Return(nil, fmt.Errorf("connection refused"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "sql rows ended early",
+			conf: config.PermissionConfig{Source: SourceSQL},
+			mockFunc: func(m mockFields) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(7, 1, entity.ActionCodeSchedulerTrigger, "").
+					AddRow(8, 1, entity.ActionCodeAll, "").
+					RowError(1, fmt.Errorf("connection reset"))
+				m.follower.EXPECT().Query(gomock.Any(), "rPermissionByRoleID", gomock.Any(), int64(1)).Return(newRows(t, rows), nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "sql row fails to scan",
+			conf: config.PermissionConfig{Source: SourceSQL},
+			mockFunc: func(m mockFields) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(7, 1, entity.ActionCodeSchedulerTrigger, "").
+					AddRow("eight", 1, entity.ActionCodeAll, "")
+				m.follower.EXPECT().Query(gomock.Any(), "rPermissionByRoleID", gomock.Any(), int64(1)).Return(newRows(t, rows), nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:     "unknown source",
+			conf:     config.PermissionConfig{Source: "ldap"},
+			mockFunc: func(m mockFields) {},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMock.EXPECT().Follower().Return(followerMock).AnyTimes()
+			followerMock.EXPECT().Rebind(gomock.Any()).DoAndReturn(func(q string) string { return q }).AnyTimes()
+			tt.mockFunc(mocks)
+
+			p := Init(logMock, dbMock, tt.conf, health.Init(health.Config{}, logMock))
+			got, err := p.GetByRoleID(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetByRoleID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetByRoleID() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_permission_GetByRoleID_cache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	dbMock := mock_sql.NewMockInterface(ctrl)
+	followerMock := mock_sql.NewMockCommand(ctrl)
+
+	dbMock.EXPECT().Follower().Return(followerMock).AnyTimes()
+	followerMock.EXPECT().Rebind(gomock.Any()).DoAndReturn(func(q string) string { return q }).AnyTimes()
+
+	// the second read is served from the cache, the query runs once
+	rows := sqlmock.NewRows([]string{"id", "fk_role_id", "action_code", "resource"}).AddRow(7, 1, entity.ActionCodeAll, "")
+	followerMock.EXPECT().Query(gomock.Any(), "rPermissionByRoleID", gomock.Any(), int64(1)).Return(newRows(t, rows), nil).Times(1)
+
+	p := Init(logMock, dbMock, config.PermissionConfig{Source: SourceSQL, CacheTTL: time.Minute}, health.Init(health.Config{}, logMock))
+	for i := 0; i < 2; i++ {
+		got, err := p.GetByRoleID(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("GetByRoleID() error = %v", err)
+		}
+		if len(got) != 1 || got[0].ActionCode != entity.ActionCodeAll {
+			t.Errorf("GetByRoleID() = %+v, want the cached permission", got)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package permission
+
+const (
+	readPermissionByRoleID = `
+		SELECT
+			id,
+			fk_role_id,
+			action_code,
+			COALESCE(resource, '') AS resource
+		FROM
+			%s
+		WHERE
+			fk_role_id = ?
+			AND status = 1
+			AND deleted_at IS NULL
+	`
+
+	readPermissionHealth = `SELECT 1 FROM %s WHERE 1 = 0`
+)
@@ -0,0 +1,10 @@
+package entity
+
+type DummyFixture struct {
+	Name     string   `json:"name"`
+	Method   string   `json:"method"`
+	Path     string   `json:"path"`
+	Status   int      `json:"status"`
+	Delay    string   `json:"delay,omitempty"`
+	Response HTTPResp `json:"response"`
+}
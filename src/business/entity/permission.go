@@ -0,0 +1,18 @@
+package entity
+
+const (
+	// ActionCodeAll grants every action code to a role
+	ActionCodeAll string = "*"
+	// ResourceAll scopes a permission to every resource of its action code
+	ResourceAll string = "*"
+
+	ActionCodeSchedulerTrigger string = "scheduler:trigger"
+	ActionCodeSchedulerView    string = "scheduler:view"
+)
+
+type Permission struct {
+	ID         int64  `db:"id" json:"id"`
+	RoleID     int64  `db:"fk_role_id" json:"roleId"`
+	ActionCode string `db:"action_code" json:"actionCode"`
+	Resource   string `db:"resource" json:"resource"`
+}
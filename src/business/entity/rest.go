@@ -20,14 +20,48 @@ type Meta struct {
 	Timestamp   string     `json:"timestamp"`
 	Error       *MetaError `json:"error,omitempty"`
 	RequestID   string     `json:"requestId"`
+	TraceID     string     `json:"traceId,omitempty"`
 	TimeElapsed string     `json:"timeElapsed"`
 }
 
 type MetaError struct {
-	Code    int    `json:"code"`
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Param   string `json:"param,omitempty"`
 	Message string `json:"message"`
 }
 
+// Problem is an RFC 7807 problem details document, the members after Instance are extensions
+// carrying what the error envelope has in its metadata
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      int          `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	Timestamp string       `json:"timestamp"`
+	RequestID string       `json:"requestId"`
+	TraceID   string       `json:"traceId,omitempty"`
+}
+
+// ProblemType describes the problem type URI of one error code
+type ProblemType struct {
+	Type   string `json:"type"`
+	Code   int    `json:"code"`
+	Status int    `json:"status"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
 type Pagination struct {
 	CurrentPage     int64    `json:"currentPage"`
 	CurrentElements int64    `json:"currentElements"`
@@ -68,6 +102,33 @@ func (p *Pagination) ProcessPagination(limit int64) {
 	}
 }
 
+// CursorParam binds keyset pagination query params, After holds the verified
+// sort key values of the cursor and is nil on the first page
+type CursorParam struct {
+	Cursor string        `form:"cursor" param:"cursor" db:"-"`
+	Limit  int64         `form:"limit" param:"limit" db:"limit"`
+	After  []interface{} `form:"-" param:"-" db:"-"`
+}
+
+// ProcessCursorPagination fills a keyset page, next is empty on the last page
+func (p *Pagination) ProcessCursorPagination(param CursorParam, count int64, next string) {
+	if p.SortBy == nil {
+		p.SortBy = []string{}
+	}
+
+	p.CurrentElements = count
+	p.CursorStart = nil
+	p.CursorEnd = nil
+
+	if param.Cursor != "" {
+		p.CursorStart = &param.Cursor
+	}
+
+	if next != "" {
+		p.CursorEnd = &next
+	}
+}
+
 type PaginationParam struct {
 	GroupBy []string `param:"-" db:"-"`
 	SortBy  []string `param:"sort_by" db:"sort_by"`
@@ -75,6 +136,8 @@ type PaginationParam struct {
 	Page    int64    `form:"page" param:"page" db:"page"`
 }
 
+// Authorize declares the action code a route requires, optionally scoped to the resource
+// identified by the uri param named Param when IsParam is "true"
 type Authorize struct {
 	Param      string
 	IsParam    string
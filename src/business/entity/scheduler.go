@@ -1,5 +1,25 @@
 package entity
 
+import "time"
+
+const (
+	SchedulerRunStarted   string = "started"
+	SchedulerRunSucceeded string = "succeeded"
+	SchedulerRunFailed    string = "failed"
+)
+
 type TriggerSchedulerParams struct {
 	Name string `json:"name"`
 }
+
+// SchedulerRunEvent is a state change of one scheduler task run, IDs only ever grow
+// so a stream can resume after the last event it received
+type SchedulerRunEvent struct {
+	ID         int64     `json:"id"`
+	Task       string    `json:"task"`
+	RunID      string    `json:"runId"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
@@ -0,0 +1,58 @@
+package permission
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/downsized-devs/sdk-go/auth"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+	permissionDom "github.com/downsized-devs/template-service-go/src/business/domain/permission"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+)
+
+type Interface interface {
+	Authorize(ctx context.Context, param entity.Authorize, resource string) error
+}
+
+type permission struct {
+	log        logger.Interface
+	auth       auth.Interface
+	permission permissionDom.Interface
+}
+
+func Init(log logger.Interface, auth auth.Interface, pd permissionDom.Interface) Interface {
+	return &permission{
+		log:        log,
+		auth:       auth,
+		permission: pd,
+	}
+}
+
+// Authorize checks the authenticated user role is granted the action code, and the resource when the
+// route is scoped to one
+func (p *permission) Authorize(ctx context.Context, param entity.Authorize, resource string) error {
+	user, err := p.auth.GetUserAuthInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	permissions, err := p.permission.GetByRoleID(ctx, user.User.RoleID)
+	if err != nil {
+		return err
+	}
+
+	scoped, _ := strconv.ParseBool(param.IsParam)
+	for _, perm := range permissions {
+		if perm.ActionCode != param.ActionCode && perm.ActionCode != entity.ActionCodeAll {
+			continue
+		}
+
+		if !scoped || perm.Resource == "" || perm.Resource == entity.ResourceAll || perm.Resource == resource {
+			return nil
+		}
+	}
+
+	return errors.NewWithCode(codes.CodeForbidden, "role %d is not allowed to %s %s", user.User.RoleID, param.ActionCode, resource)
+}
@@ -0,0 +1,111 @@
+package permission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/auth"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	mock_auth "github.com/downsized-devs/sdk-go/tests/mock/auth"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	mock_sql "github.com/downsized-devs/sdk-go/tests/mock/sql"
+	permissionDom "github.com/downsized-devs/template-service-go/src/business/domain/permission"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/health"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_permission_Authorize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	authMock := mock_auth.NewMockInterface(ctrl)
+
+	// role 1 triggers any task, role 2 holds every action code, role 3 has no permission
+	pd := permissionDom.Init(logMock, mock_sql.NewMockInterface(ctrl), config.PermissionConfig{
+		Source: permissionDom.SourceConfig,
+		Roles: map[string][]string{
+			"1": {entity.ActionCodeSchedulerTrigger},
+			"2": {entity.ActionCodeAll},
+		},
+	}, health.Init(health.Config{}, logMock))
+
+	trigger := entity.Authorize{ActionCode: entity.ActionCodeSchedulerTrigger}
+
+	type args struct {
+		param    entity.Authorize
+		resource string
+	}
+	tests := []struct {
+		name     string
+		mockFunc func()
+		args     args
+		wantCode codes.Code
+	}{
+		{
+			name: "granted",
+			mockFunc: func() {
+				authMock.EXPECT().GetUserAuthInfo(gomock.Any()).Return(auth.UserAuthInfo{User: auth.User{RoleID: 1}}, nil)
+			},
+			args: args{param: trigger},
+		},
+		{
+			name: "granted every action code",
+			mockFunc: func() {
+				authMock.EXPECT().GetUserAuthInfo(gomock.Any()).Return(auth.UserAuthInfo{User: auth.User{RoleID: 2}}, nil)
+			},
+			args: args{param: trigger},
+		},
+		{
+			name: "granted on every resource",
+			mockFunc: func() {
+				authMock.EXPECT().GetUserAuthInfo(gomock.Any()).Return(auth.UserAuthInfo{User: auth.User{RoleID: 1}}, nil)
+			},
+			args: args{param: entity.Authorize{Param: "task", IsParam: "true", ActionCode: entity.ActionCodeSchedulerTrigger}, resource: "cleanup"},
+		},
+		{
+			name: "other action code",
+			mockFunc: func() {
+				authMock.EXPECT().GetUserAuthInfo(gomock.Any()).Return(auth.UserAuthInfo{User: auth.User{RoleID: 1}}, nil)
+			},
+			args:     args{param: entity.Authorize{ActionCode: "order:delete"}},
+			wantCode: codes.CodeForbidden,
+		},
+		{
+			name: "role without permissions",
+			mockFunc: func() {
+				authMock.EXPECT().GetUserAuthInfo(gomock.Any()).Return(auth.UserAuthInfo{User: auth.User{RoleID: 3}}, nil)
+			},
+			args:     args{param: trigger},
+			wantCode: codes.CodeForbidden,
+		},
+		{
+			name: "no authenticated user",
+			mockFunc: func() {
+				authMock.EXPECT().GetUserAuthInfo(gomock.Any()).Return(auth.UserAuthInfo{}, errors.NewWithCode(codes.CodeUnauthorized, "no user"))
+			},
+			args:     args{param: trigger},
+			wantCode: codes.CodeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFunc()
+
+			p := Init(logMock, authMock, pd)
+			err := p.Authorize(context.Background(), tt.args.param, tt.args.resource)
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Errorf("Authorize() error = %v, want nil", err)
+				}
+				return
+			}
+			if code := errors.GetCode(err); code != tt.wantCode {
+				t.Errorf("Authorize() error code = %v, want %v", code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"github.com/downsized-devs/sdk-go/logger"
 	"github.com/downsized-devs/sdk-go/parser"
 	"github.com/downsized-devs/template-service-go/src/business/domain"
+	"github.com/downsized-devs/template-service-go/src/business/usecase/permission"
 )
 
 type Usecases struct {
 	// Add usecase package interfaces here
+	Permission permission.Interface
 }
 
 type InitParam struct {
@@ -19,7 +21,9 @@ type InitParam struct {
 }
 
 func Init(param InitParam) *Usecases {
-	dom := &Usecases{}
+	dom := &Usecases{
+		Permission: permission.Init(param.Log, param.Auth, param.Dom.Permission),
+	}
 
 	return dom
 }
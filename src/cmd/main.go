@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/downsized-devs/sdk-go/auth"
@@ -14,7 +15,15 @@ import (
 	"github.com/downsized-devs/template-service-go/src/business/usecase"
 	"github.com/downsized-devs/template-service-go/src/handler/rest"
 	"github.com/downsized-devs/template-service-go/src/handler/scheduler"
+	"github.com/downsized-devs/template-service-go/src/utils/accesslog"
 	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/cursor"
+	"github.com/downsized-devs/template-service-go/src/utils/health"
+	"github.com/downsized-devs/template-service-go/src/utils/idempotency"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"github.com/downsized-devs/template-service-go/src/utils/ratelimiter"
+	"github.com/downsized-devs/template-service-go/src/utils/tlsconfig"
+	"github.com/downsized-devs/template-service-go/src/utils/tracer"
 )
 
 // @contact.name   Alvin Radeka
@@ -49,23 +58,57 @@ func main() {
 	})
 	configreader.ReadConfig(&cfg)
 
-	// init logger
-	log := logger.Init(cfg.Log)
+	// init logger, log lines carry the trace id of their context
+	log := tracer.WrapLogger(logger.Init(cfg.Log))
 
 	// init parser
 	parser := parser.InitParser(log, cfg.Parser)
 
+	// init metrics
+	metrics := metrics.Init(cfg.Metrics)
+
+	// init access log
+	accessLog := accesslog.Init(cfg.Gin.AccessLog, log, parser.JsonParser())
+
+	// init tls, certificates are reloaded when they change on disk
+	tlsConf := tlsconfig.Init(cfg.Gin.TLS, log)
+
+	// init tracer
+	tracer := tracer.Init(cfg.Tracer, log)
+
+	// init outbound http client, traced through the tracer transport
+	httpClient := &http.Client{
+		Transport: tracer.Transport(http.DefaultTransport),
+	}
+
 	// init db conn
 	db := sql.Init(cfg.SQL, log, nil)
 
+	// init health checks, domains and handlers register their own on top of these
+	hc := health.Init(cfg.Health, log)
+	hc.Register("sql_leader", db.Leader().Ping)
+	hc.Register("sql_follower", db.Follower().Ping)
+
+	// init rate limiter store
+	rl := ratelimiter.Init(cfg.RateLimiter, log, db)
+
+	// init idempotency store
+	idem := idempotency.Init(cfg.Idempotency, log, parser.JsonParser(), db)
+
+	// init keyset pagination cursors
+	cur := cursor.Init(cfg.Cursor, log, parser.JsonParser())
+
 	// init auth
-	auth := auth.Init(auth.Config{}, log, parser.JsonParser(), &http.Client{})
+	auth := auth.Init(auth.Config{}, log, parser.JsonParser(), httpClient)
 
 	// init all domain
 	dom := domain.Init(domain.InitParam{
 		Log:    log,
 		Db:     db,
 		Parser: parser,
+		Http:   httpClient,
+		Conf:   cfg.Business,
+		Health: hc,
 	})
 
 	// init all uc
@@ -76,6 +119,10 @@ func main() {
 		Auth:   auth,
 	})
 
+	// init scheduler
+	sch := scheduler.Init(cfg.Scheduler, log, auth, uc, metrics, tracer, idem, rl)
+	hc.Register("scheduler", sch.HealthCheck)
+
 	// init http server
 	r := rest.Init(rest.InitParam{
 		Conf:         cfg.Gin,
@@ -84,14 +131,26 @@ func main() {
 		Json:         parser.JsonParser(),
 		Uc:           uc,
 		Auth:         auth,
+		Scheduler:    sch,
+		Metrics:      metrics,
+		Tracer:       tracer,
+		RateLimiter:  rl,
+		Idempotency:  idem,
+		AccessLog:    accessLog,
+		Health:       hc,
+		TLS:          tlsConf,
+		Cursor:       cur,
 	})
 
-	// init scheduler
-	sch := scheduler.Init(cfg.Scheduler, log, auth, uc)
-
 	// run scheduler
 	sch.Run()
 
-	// run the http server
+	// run the http server, it returns once the server and the scheduler are shut down
 	r.Run()
+
+	// close the sql pools once nothing can use them anymore
+	db.Stop()
+
+	// flush remaining spans
+	tracer.Stop(context.Background())
 }
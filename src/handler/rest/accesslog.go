@@ -0,0 +1,132 @@
+package rest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/appcontext"
+	"github.com/downsized-devs/sdk-go/header"
+	"github.com/downsized-devs/template-service-go/src/utils/accesslog"
+	"github.com/downsized-devs/template-service-go/src/utils/tracer"
+	"github.com/gin-gonic/gin"
+)
+
+// bodyCapture keeps the first limit bytes written through it and counts the rest
+type bodyCapture struct {
+	buf       bytes.Buffer
+	limit     int
+	size      int64
+	truncated bool
+}
+
+func (b *bodyCapture) capture(p []byte) {
+	b.size += int64(len(p))
+	if b.buf.Len()+len(p) > b.limit {
+		b.truncated = true
+		return
+	}
+	b.buf.Write(p)
+}
+
+type accessLogReader struct {
+	io.ReadCloser
+	body *bodyCapture
+}
+
+func (r *accessLogReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.body.capture(p[:n])
+	return n, err
+}
+
+type accessLogWriter struct {
+	gin.ResponseWriter
+	body *bodyCapture
+}
+
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	w.body.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *accessLogWriter) WriteString(s string) (int, error) {
+	w.body.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// AccessLog writes one structured entry per request once the whole chain has run
+func (r *rest) AccessLog(ctx *gin.Context) {
+	if !r.accesslog.IsEnabled() {
+		ctx.Next()
+		return
+	}
+
+	start := time.Now()
+	// the request body is always counted but only kept when it is going to be logged
+	reqBody := &bodyCapture{}
+	if r.conf.AccessLog.RequestBody {
+		reqBody.limit = r.accesslog.MaxBodySize()
+	}
+	if ctx.Request.Body != nil {
+		ctx.Request.Body = &accessLogReader{ReadCloser: ctx.Request.Body, body: reqBody}
+	}
+
+	// the response is counted here too, the bytes on the wire are only known once Compress
+	// wrapping this middleware finishes the encoded stream
+	respBody := &bodyCapture{}
+	if r.conf.AccessLog.ResponseBody {
+		respBody.limit = r.accesslog.MaxBodySize()
+	}
+	ctx.Writer = &accessLogWriter{ResponseWriter: ctx.Writer, body: respBody}
+
+	ctx.Next()
+
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	// downstream middlewares replace the request, so the context holds everything set along the chain
+	c := ctx.Request.Context()
+	requestID := appcontext.GetRequestId(c)
+	if requestID == "" {
+		requestID = ctx.Writer.Header().Get(header.KeyRequestID)
+	}
+
+	userID := ""
+	if user, err := r.auth.GetUserAuthInfo(c); err == nil {
+		userID = user.User.UID
+	}
+
+	entry := accesslog.Entry{
+		Method:               ctx.Request.Method,
+		Route:                route,
+		Path:                 ctx.Request.URL.RequestURI(),
+		Status:               ctx.Writer.Status(),
+		AppCode:              int(appcontext.GetAppResponseCode(c)),
+		Latency:              time.Since(start),
+		BytesIn:              reqBody.size,
+		UncompressedBytesOut: respBody.size,
+		ClientIP:             ctx.ClientIP(),
+		UserID:               userID,
+		RequestID:            requestID,
+		TraceID:              tracer.TraceID(c),
+		UserAgent:            ctx.Request.UserAgent(),
+	}
+
+	if r.conf.AccessLog.RequestBody {
+		entry.RequestBody = r.accesslog.Redact(reqBody.buf.Bytes(), reqBody.truncated)
+	}
+
+	if r.conf.AccessLog.ResponseBody {
+		entry.ResponseBody = r.accesslog.Redact(respBody.buf.Bytes(), respBody.truncated)
+	}
+
+	r.accesslog.Log(c, entry)
+}
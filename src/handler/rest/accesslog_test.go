@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/template-service-go/src/utils/accesslog"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/gin-gonic/gin"
+)
+
+// accessLogRecorder keeps the entries instead of writing them
+type accessLogRecorder struct {
+	accesslog.Interface
+	entries []accesslog.Entry
+}
+
+func (a *accessLogRecorder) Log(ctx context.Context, entry accesslog.Entry) {
+	a.entries = append(a.entries, entry)
+}
+
+func Test_rest_AccessLog_compressed(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{
+		AccessLog:   accesslog.Config{Enabled: true, ResponseBody: true, MaxBodySize: 1 << 16},
+		Compression: config.CompressionConfig{Enabled: true, Encodings: []string{encodingGzip}},
+	})
+	recorder := &accessLogRecorder{Interface: accesslog.Init(accesslog.Config{Enabled: true, MaxBodySize: 1 << 16}, r.log, r.json)}
+	r.accesslog = recorder
+
+	payload := strings.Repeat("logged ", 300)
+	r.http.Use(r.Compress, r.AccessLog)
+	r.http.GET("/report", func(ctx *gin.Context) {
+		r.httpRespSuccess(ctx, codes.CodeSuccess, payload, nil)
+	})
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{
+			name:           "gzip",
+			acceptEncoding: encodingGzip,
+			wantEncoding:   encodingGzip,
+		},
+		{
+			name:           "identity",
+			acceptEncoding: "identity",
+			wantEncoding:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.entries = nil
+
+			req := httptest.NewRequest(http.MethodGet, "/report", nil)
+			req.Header.Set(headerAcceptEncoding, tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get(headerContentEncoding); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+
+			if len(recorder.entries) != 1 {
+				t.Fatalf("got %d access log entries, want 1", len(recorder.entries))
+			}
+			entry := recorder.entries[0]
+
+			if !strings.Contains(entry.ResponseBody, payload) {
+				t.Errorf("ResponseBody = %.80q, want the json body", entry.ResponseBody)
+			}
+			if entry.UncompressedBytesOut <= int64(len(payload)) {
+				t.Errorf("UncompressedBytesOut = %d, want the unencoded size over %d", entry.UncompressedBytesOut, len(payload))
+			}
+		})
+	}
+}
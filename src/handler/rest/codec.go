@@ -0,0 +1,367 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/header"
+	"github.com/downsized-devs/sdk-go/parser"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	ugorji "github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	codecJSON    string = "json"
+	codecYAML    string = "yaml"
+	codecMsgpack string = "msgpack"
+	codecCBOR    string = "cbor"
+
+	headerAccept string = "Accept"
+	mediaAny     string = "*/*"
+)
+
+// codec encodes and decodes one media type. Every codec goes through the json form of a value,
+// so json tags and custom json marshallers shape the output of all formats the same way
+type codec struct {
+	name        string
+	contentType string
+	// aliases are other media types accepted for the same format
+	aliases []string
+	// encode and decode convert between the format and generic json values
+	encode func(v interface{}) ([]byte, error)
+	decode func(data []byte) (interface{}, error)
+}
+
+type codecs struct {
+	json parser.JsonInterface
+	list []codec
+}
+
+// newCodecs is the single place response and request formats are registered, json stays
+// first so it wins when the client accepts anything
+func newCodecs(json parser.JsonInterface) *codecs {
+	msgpack := &ugorji.MsgpackHandle{WriteExt: true}
+	msgpack.RawToString = true
+	msgpack.MapType = reflect.TypeOf(map[string]interface{}(nil))
+
+	cbor := &ugorji.CborHandle{}
+	cbor.MapType = reflect.TypeOf(map[string]interface{}(nil))
+
+	return &codecs{
+		json: json,
+		list: []codec{
+			{
+				name:        codecJSON,
+				contentType: header.ContentTypeJSON,
+			},
+			// yaml v3 follows YAML 1.2, so keys like n or no stay strings instead of turning into booleans
+			{
+				name:        codecYAML,
+				contentType: binding.MIMEYAML2,
+				aliases:     []string{binding.MIMEYAML, "text/yaml"},
+				encode:      yaml.Marshal,
+				decode: func(data []byte) (interface{}, error) {
+					var v interface{}
+					err := yaml.Unmarshal(data, &v)
+					return v, err
+				},
+			},
+			{
+				name:        codecMsgpack,
+				contentType: binding.MIMEMSGPACK2,
+				aliases:     []string{binding.MIMEMSGPACK, "application/vnd.msgpack"},
+				encode:      ugorjiEncode(msgpack),
+				decode:      ugorjiDecode(msgpack),
+			},
+			{
+				name:        codecCBOR,
+				contentType: "application/cbor",
+				encode:      ugorjiEncode(cbor),
+				decode:      ugorjiDecode(cbor),
+			},
+		},
+	}
+}
+
+func ugorjiEncode(h ugorji.Handle) func(v interface{}) ([]byte, error) {
+	return func(v interface{}) ([]byte, error) {
+		out := []byte{}
+		err := ugorji.NewEncoderBytes(&out, h).Encode(v)
+		return out, err
+	}
+}
+
+func ugorjiDecode(h ugorji.Handle) func(data []byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		var v interface{}
+		err := ugorji.NewDecoderBytes(data, h).Decode(&v)
+		return v, err
+	}
+}
+
+func (c *codecs) byName(name string) (codec, bool) {
+	for _, cd := range c.list {
+		if cd.name == name {
+			return cd, true
+		}
+	}
+
+	return codec{}, false
+}
+
+func (c *codecs) byContentType(contentType string) (codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return codec{}, false
+	}
+
+	for _, cd := range c.list {
+		if cd.matches(mediaType) {
+			return cd, true
+		}
+	}
+
+	return codec{}, false
+}
+
+// negotiate picks the codec the Accept header prefers, following the q values and the
+// most specific matching range. A missing header means json
+func (c *codecs) negotiate(accept string) (codec, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return c.list[0], true
+	}
+
+	ranges := parseAccept(accept)
+
+	best, bestQ := codec{}, 0.0
+	for _, cd := range c.list {
+		if q := cd.quality(ranges); q > bestQ {
+			best, bestQ = cd, q
+		}
+	}
+
+	return best, bestQ > 0
+}
+
+func (cd codec) matches(mediaType string) bool {
+	if mediaType == cd.contentType {
+		return true
+	}
+
+	for _, alias := range cd.aliases {
+		if mediaType == alias {
+			return true
+		}
+	}
+
+	return false
+}
+
+// quality returns the q value of the most specific range matching the codec
+func (cd codec) quality(ranges []acceptRange) float64 {
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case cd.matches(r.mediaType):
+			s = 2
+		case strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(cd.contentType, strings.TrimSuffix(r.mediaType, "*")):
+			s = 1
+		case r.mediaType == mediaAny:
+			s = 0
+		}
+
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+
+	return q
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+func parseAccept(accept string) []acceptRange {
+	ranges := []acceptRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 && parsed <= 1 {
+				q = parsed
+			}
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+
+	return ranges
+}
+
+// marshal renders v in the codec format
+func (c *codecs) marshal(cd codec, v interface{}) ([]byte, error) {
+	raw, err := c.json.Marshal(v)
+	if err != nil || cd.encode == nil {
+		return raw, err
+	}
+
+	generic, err := decodeGenericJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return cd.encode(generic)
+}
+
+// toJSON turns a request body of the codec format into json, ready for the json binding
+func (c *codecs) toJSON(cd codec, body []byte) ([]byte, error) {
+	if cd.decode == nil {
+		return body, nil
+	}
+
+	generic, err := cd.decode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, err := normalizeGeneric(generic)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.json.Marshal(normalized)
+}
+
+// decodeGenericJSON keeps integers exact instead of turning every number into a float64
+func decodeGenericJSON(raw []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return convertNumbers(v), nil
+}
+
+func convertNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = convertNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = convertNumbers(item)
+		}
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(val.String(), 10, 64); err == nil {
+			return u
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	}
+
+	return v
+}
+
+// normalizeGeneric makes decoded yaml, msgpack and cbor values json friendly,
+// mostly turning map[interface{}]interface{} into string keyed maps
+func normalizeGeneric(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			n, err := normalizeGeneric(item)
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(k)] = n
+		}
+		return out, nil
+	case map[string]interface{}:
+		for k, item := range val {
+			n, err := normalizeGeneric(item)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = n
+		}
+		return val, nil
+	case []interface{}:
+		for i, item := range val {
+			n, err := normalizeGeneric(item)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = n
+		}
+		return val, nil
+	case []byte:
+		return string(val), nil
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return nil, errors.NewWithCode(codes.CodeBadRequest, "numbers must be finite")
+		}
+	}
+
+	return v, nil
+}
+
+// responseCodec negotiates the response format of the request, notAcceptable is set when the
+// client accepts none of the registered formats and the caller falls back to json
+func (r *rest) responseCodec(ctx *gin.Context) (cd codec, notAcceptable bool) {
+	addVary(ctx.Writer.Header(), headerAccept)
+
+	cd, ok := r.codecs.negotiate(ctx.GetHeader(headerAccept))
+	if !ok {
+		return r.codecs.list[0], true
+	}
+
+	return cd, false
+}
+
+// addVary adds value to the Vary header unless a previous response attempt already did
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values(headerVary) {
+		for _, existing := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(existing), value) {
+				return
+			}
+		}
+	}
+
+	h.Add(headerVary, value)
+}
+
+func errNotAcceptable(accept string) error {
+	return withStatus(errors.NewWithCode(codes.CodeBadRequest, "none of the accepted media types %q is supported", accept), http.StatusNotAcceptable)
+}
+
+func errUnsupportedMediaType(contentType string) error {
+	return withStatus(errors.NewWithCode(codes.CodeBadRequest, "request content type %q is not supported", contentType), http.StatusUnsupportedMediaType)
+}
@@ -0,0 +1,286 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/downsized-devs/sdk-go/header"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingGzip   string = "gzip"
+	encodingBrotli string = "br"
+	encodingZstd   string = "zstd"
+
+	headerAcceptEncoding  string = "Accept-Encoding"
+	headerContentEncoding string = "Content-Encoding"
+	headerContentLength   string = "Content-Length"
+	headerVary            string = "Vary"
+
+	cacheControlNoTransform string = "no-transform"
+
+	defaultCompressMinSize int = 1024
+)
+
+var (
+	defaultCompressEncodings = []string{encodingBrotli, encodingZstd, encodingGzip}
+
+	defaultCompressContentTypes = []string{
+		"application/json",
+		"application/problem+json",
+		"application/yaml",
+		"application/xml",
+		"application/javascript",
+		"image/svg+xml",
+		"text/*",
+	}
+)
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressPools keeps one pool of reusable encoders per content encoding
+var compressPools = map[string]*sync.Pool{
+	encodingGzip: {New: func() any {
+		return gzip.NewWriter(io.Discard)
+	}},
+	encodingBrotli: {New: func() any {
+		return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression)
+	}},
+	encodingZstd: {New: func() any {
+		enc, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+		return enc
+	}},
+}
+
+// compressWriter holds the response back until MinSize bytes are written, then decides
+// once whether the body is worth compressing
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding     string
+	minSize      int
+	contentTypes []string
+	buf          bytes.Buffer
+	decided      bool
+	enc          compressor
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		return w.write(b)
+	}
+
+	w.buf.Write(b)
+	if w.buf.Len() >= w.minSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) write(b []byte) (int, error) {
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// decide starts compressing when the response is compressible and writes out the held back bytes
+func (w *compressWriter) decide() error {
+	w.decided = true
+
+	if w.compressible() {
+		h := w.Header()
+		h.Set(headerContentEncoding, w.encoding)
+		h.Del(headerContentLength)
+		if etag := h.Get(headerETag); etag != "" {
+			h.Set(headerETag, codedETag(etag, w.encoding))
+		}
+
+		w.enc = compressPools[w.encoding].Get().(compressor)
+		w.enc.Reset(w.ResponseWriter)
+	}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := w.write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+
+	// the handler already encoded the body itself
+	if w.Header().Get(headerContentEncoding) != "" {
+		return false
+	}
+
+	// no-transform asks for the body to be sent exactly as written
+	if strings.Contains(strings.ToLower(w.Header().Get(header.KeyCacheControl)), cacheControlNoTransform) {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get(header.KeyContentType))
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range w.contentTypes {
+		if allowed == mediaType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// close flushes what is still held back uncompressed, or finishes the compressed stream
+func (w *compressWriter) close() error {
+	if !w.decided {
+		w.decided = true
+		if w.buf.Len() > 0 {
+			_, err := w.ResponseWriter.Write(w.buf.Bytes())
+			return err
+		}
+		return nil
+	}
+
+	if w.enc == nil {
+		return nil
+	}
+
+	err := w.enc.Close()
+	w.enc.Reset(io.Discard)
+	compressPools[w.encoding].Put(w.enc)
+	w.enc = nil
+
+	return err
+}
+
+// Compress encodes responses with the best encoding the client accepts out of the configured ones
+func (r *rest) Compress(ctx *gin.Context) {
+	if !r.conf.Compression.Enabled {
+		ctx.Next()
+		return
+	}
+
+	// caches must keep one copy per encoding, even when this response ends up uncompressed
+	ctx.Writer.Header().Add(headerVary, headerAcceptEncoding)
+
+	encoding := negotiateEncoding(ctx.GetHeader(headerAcceptEncoding), withDefault(r.conf.Compression.Encodings, defaultCompressEncodings))
+	if encoding == "" || ctx.Request.Method == http.MethodHead {
+		ctx.Next()
+		return
+	}
+
+	minSize := r.conf.Compression.MinSize
+	if minSize <= 0 {
+		minSize = defaultCompressMinSize
+	}
+
+	// lets a conditional request match the etag of the encoded body it was sent before
+	ctx.Set(ctxKeyContentCoding, encoding)
+
+	w := &compressWriter{
+		ResponseWriter: ctx.Writer,
+		encoding:       encoding,
+		minSize:        minSize,
+		contentTypes:   withDefault(r.conf.Compression.ContentTypes, defaultCompressContentTypes),
+	}
+	ctx.Writer = w
+	ctx.Next()
+
+	if err := w.close(); err != nil {
+		r.log.Error(ctx.Request.Context(), fmt.Sprintf("Closing %s response writer error: %s", encoding, err.Error()))
+	}
+}
+
+// initCompress makes sure every configured encoding has an encoder before serving
+func (r *rest) initCompress() gin.HandlerFunc {
+	for _, enc := range r.conf.Compression.Encodings {
+		if _, ok := compressPools[enc]; !ok {
+			r.log.Fatal(context.Background(), fmt.Sprintf("Unsupported compression encoding %q", enc))
+		}
+	}
+
+	return r.Compress
+}
+
+// negotiateEncoding picks the supported encoding with the highest q value in Accept-Encoding,
+// ties are broken by the order of supported
+func negotiateEncoding(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range supported {
+		q, ok := accepted[enc]
+		if !ok {
+			q = accepted["*"]
+		}
+
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+
+	return best
+}
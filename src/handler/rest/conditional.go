@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/null"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerETag            string = "ETag"
+	headerIfNoneMatch     string = "If-None-Match"
+	headerIfModifiedSince string = "If-Modified-Since"
+	headerLastModified    string = "Last-Modified"
+	headerAcceptLanguage  string = "Accept-Language"
+
+	ctxKeyLastModified  string = "rest.lastModified"
+	ctxKeyContentCoding string = "rest.contentCoding"
+	etagWeakPrefix      string = "W/"
+)
+
+// SetLastModified records updatedAt as the response Last-Modified when it is the latest seen so far,
+// list handlers can call it for every item they return
+func (r *rest) SetLastModified(ctx *gin.Context, updatedAt null.Time) {
+	if !updatedAt.Valid || updatedAt.Time.IsZero() {
+		return
+	}
+
+	if last, ok := ctx.Get(ctxKeyLastModified); ok && !updatedAt.Time.After(last.(time.Time)) {
+		return
+	}
+
+	ctx.Set(ctxKeyLastModified, updatedAt.Time)
+}
+
+// checkNotModified sets the validators of a GET response and reports whether the client copy is
+// still fresh. If-None-Match takes precedence over If-Modified-Since as in RFC 9110. The etag
+// stays strong, Compress marks it with the content coding when it encodes the body
+func checkNotModified(ctx *gin.Context, payload []byte) bool {
+	sum := sha256.Sum256(payload)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	ctx.Header(headerETag, etag)
+
+	var lastModified time.Time
+	if v, ok := ctx.Get(ctxKeyLastModified); ok {
+		lastModified = v.(time.Time).UTC().Truncate(time.Second)
+		ctx.Header(headerLastModified, lastModified.Format(http.TimeFormat))
+	}
+
+	if inm := ctx.GetHeader(headerIfNoneMatch); inm != "" {
+		if matchETag(inm, etag) {
+			return true
+		}
+
+		// the client copy may be the encoded one, which only holds when this request negotiated the same coding
+		coded := codedETag(etag, ctx.GetString(ctxKeyContentCoding))
+		if coded != etag && matchETag(inm, coded) {
+			ctx.Header(headerETag, coded)
+			return true
+		}
+
+		return false
+	}
+
+	if ims := ctx.GetHeader(headerIfModifiedSince); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		return err == nil && !lastModified.After(since)
+	}
+
+	return false
+}
+
+// codedETag tells a strong etag apart per content coding, the encoded body is a different
+// representation of the same payload
+func codedETag(etag, coding string) string {
+	if coding == "" || !strings.HasSuffix(etag, `"`) || strings.HasPrefix(etag, etagWeakPrefix) {
+		return etag
+	}
+
+	return strings.TrimSuffix(etag, `"`) + "-" + coding + `"`
+}
+
+// matchETag uses the weak comparison If-None-Match calls for
+func matchETag(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, etagWeakPrefix) == strings.TrimPrefix(etag, etagWeakPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
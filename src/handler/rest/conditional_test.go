@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/language"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/gin-gonic/gin"
+)
+
+func Test_rest_httpRespSuccess_etag(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{})
+	r.http.GET("/item", r.addFieldsToContext, func(ctx *gin.Context) {
+		r.httpRespSuccess(ctx, codes.CodeSuccess, map[string]string{"name": "item"}, nil)
+	})
+
+	get := func(lang, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/item", nil)
+		req.Header.Set(headerAcceptLanguage, lang)
+		if ifNoneMatch != "" {
+			req.Header.Set(headerIfNoneMatch, ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		r.http.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get(language.English, "")
+	etag := first.Header().Get(headerETag)
+	if etag == "" || strings.HasPrefix(etag, etagWeakPrefix) {
+		t.Fatalf("ETag = %q, want a strong etag", etag)
+	}
+	if got := strings.Join(first.Header().Values(headerVary), ", "); !strings.Contains(got, headerAcceptLanguage) {
+		t.Errorf("Vary = %q, want it to list %s", got, headerAcceptLanguage)
+	}
+
+	tests := []struct {
+		name        string
+		lang        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{
+			name:        "same language",
+			lang:        language.English,
+			ifNoneMatch: etag,
+			wantStatus:  http.StatusNotModified,
+		},
+		{
+			name:        "weak form of the etag",
+			lang:        language.English,
+			ifNoneMatch: etagWeakPrefix + etag,
+			wantStatus:  http.StatusNotModified,
+		},
+		{
+			name:        "other language",
+			lang:        language.Indonesian,
+			ifNoneMatch: etag,
+			wantStatus:  http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := get(tt.lang, tt.ifNoneMatch).Code; got != tt.wantStatus {
+				t.Errorf("status = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func Test_rest_httpRespSuccess_etagCoding(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{
+		Compression: config.CompressionConfig{Enabled: true, Encodings: []string{encodingGzip}, MinSize: 1},
+	})
+	r.http.Use(r.Compress)
+	r.http.GET("/item", r.addFieldsToContext, func(ctx *gin.Context) {
+		r.httpRespSuccess(ctx, codes.CodeSuccess, map[string]string{"name": "item"}, nil)
+	})
+
+	get := func(acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/item", nil)
+		req.Header.Set(headerAcceptEncoding, acceptEncoding)
+		if ifNoneMatch != "" {
+			req.Header.Set(headerIfNoneMatch, ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		r.http.ServeHTTP(rec, req)
+		return rec
+	}
+
+	identity := get("identity", "").Header().Get(headerETag)
+	gzipped := get(encodingGzip, "").Header().Get(headerETag)
+	if gzipped != codedETag(identity, encodingGzip) || gzipped == identity {
+		t.Fatalf("gzip ETag = %q, want %q marked with the coding", gzipped, identity)
+	}
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		ifNoneMatch    string
+		wantStatus     int
+		wantETag       string
+	}{
+		{
+			name:           "gzip copy revalidated with gzip",
+			acceptEncoding: encodingGzip,
+			ifNoneMatch:    gzipped,
+			wantStatus:     http.StatusNotModified,
+			wantETag:       gzipped,
+		},
+		{
+			name:           "identity copy revalidated with identity",
+			acceptEncoding: "identity",
+			ifNoneMatch:    identity,
+			wantStatus:     http.StatusNotModified,
+			wantETag:       identity,
+		},
+		{
+			name:           "gzip copy revalidated with identity",
+			acceptEncoding: "identity",
+			ifNoneMatch:    gzipped,
+			wantStatus:     http.StatusOK,
+			wantETag:       identity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(tt.acceptEncoding, tt.ifNoneMatch)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get(headerETag); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/header"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	corsModeAllowAll  string = "allowall"
+	corsModeAllowList string = "allowlist"
+
+	corsWildcardSubdomain string = "*."
+	corsConfigError       string = "Invalid CORS config for env %q: %s"
+
+	defaultCORSMaxAge time.Duration = 12 * time.Hour
+)
+
+var (
+	defaultCORSAllowMethods = []string{
+		http.MethodHead,
+		http.MethodGet,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+		http.MethodOptions,
+	}
+
+	defaultCORSAllowHeaders = []string{
+		"Origin",
+		header.KeyContentType,
+		header.KeyAuthorization,
+		header.KeyRequestID,
+		header.KeyAcceptLanguage,
+		headerIdempotencyKey,
+		headerIfNoneMatch,
+		headerIfModifiedSince,
+	}
+
+	// headers the browser hides from scripts unless they are exposed explicitly
+	defaultCORSExposeHeaders = []string{
+		header.KeyRequestID,
+		headerRateLimitLimit,
+		headerRateLimitRemaining,
+		headerRateLimitReset,
+		headerRetryAfter,
+		headerIdempotencyReplayed,
+		headerETag,
+	}
+)
+
+// initCORS builds the cors middleware for the current environment, stopping the
+// service when the policy is misconfigured rather than silently blocking browsers
+func (r *rest) initCORS() gin.HandlerFunc {
+	conf := r.conf.CORS
+	if envConf, ok := conf.Environments[r.conf.Env]; ok {
+		conf = envConf
+	}
+
+	corsConf, err := buildCORSConfig(conf)
+	if err != nil {
+		r.log.Fatal(context.Background(), fmt.Sprintf(corsConfigError, r.conf.Env, err.Error()))
+	}
+
+	return cors.New(corsConf)
+}
+
+func buildCORSConfig(conf config.CORSConfig) (cors.Config, error) {
+	corsConf := cors.Config{
+		AllowMethods:     withDefault(conf.AllowMethods, defaultCORSAllowMethods),
+		AllowHeaders:     withDefault(conf.AllowHeaders, defaultCORSAllowHeaders),
+		ExposeHeaders:    withDefault(conf.ExposeHeaders, defaultCORSExposeHeaders),
+		AllowCredentials: conf.AllowCredentials,
+		MaxAge:           conf.MaxAge,
+	}
+
+	if corsConf.MaxAge <= 0 {
+		corsConf.MaxAge = defaultCORSMaxAge
+	}
+
+	switch conf.Mode {
+	case "":
+		// an unset mode keeps the gin-contrib defaults the service started with, those allow
+		// no origin until AllowOrigins lists one
+		defaults := cors.DefaultConfig()
+		corsConf.AllowMethods = withDefault(conf.AllowMethods, defaults.AllowMethods)
+		corsConf.AllowHeaders = withDefault(conf.AllowHeaders, defaults.AllowHeaders)
+		corsConf.AllowOrigins = conf.AllowOrigins
+	case corsModeAllowAll:
+		// browsers refuse credentials when the allowed origin is '*'
+		if conf.AllowCredentials {
+			return corsConf, errors.NewWithCode(codes.CodeInvalidValue, "AllowCredentials can not be used with mode %s, list the origins instead", corsModeAllowAll)
+		}
+		corsConf.AllowAllOrigins = true
+		if len(conf.AllowHeaders) == 0 {
+			corsConf.AllowHeaders = []string{"*"}
+		}
+	case corsModeAllowList:
+		if len(conf.AllowOrigins) == 0 {
+			return corsConf, errors.NewWithCode(codes.CodeInvalidValue, "mode %s needs at least one origin in AllowOrigins", corsModeAllowList)
+		}
+
+		for _, origin := range conf.AllowOrigins {
+			if err := validateCORSOrigin(origin); err != nil {
+				return corsConf, err
+			}
+
+			if strings.Contains(origin, corsWildcardSubdomain) {
+				corsConf.AllowWildcard = true
+			}
+		}
+		corsConf.AllowOrigins = conf.AllowOrigins
+	default:
+		return corsConf, errors.NewWithCode(codes.CodeInvalidValue, "unknown mode %q, use %s or %s", conf.Mode, corsModeAllowAll, corsModeAllowList)
+	}
+
+	if err := corsConf.Validate(); err != nil {
+		return corsConf, errors.NewWithCode(codes.CodeInvalidValue, "%s", err.Error())
+	}
+
+	return corsConf, nil
+}
+
+// validateCORSOrigin accepts scheme://host[:port] origins, where the host may start with
+// a single '*.' label to allow every subdomain, e.g. https://*.example.com
+func validateCORSOrigin(origin string) error {
+	u, err := url.Parse(strings.Replace(origin, corsWildcardSubdomain, "", 1))
+	if err != nil {
+		return errors.NewWithCode(codes.CodeInvalidValue, "origin %q: %s", origin, err.Error())
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.NewWithCode(codes.CodeInvalidValue, "origin %q must use http or https", origin)
+	}
+
+	if u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return errors.NewWithCode(codes.CodeInvalidValue, "origin %q must be scheme://host[:port] without path or trailing slash", origin)
+	}
+
+	if wildcards := strings.Count(origin, "*"); wildcards > 1 ||
+		(wildcards == 1 && !strings.HasPrefix(origin, u.Scheme+"://"+corsWildcardSubdomain)) {
+		return errors.NewWithCode(codes.CodeInvalidValue, "origin %q may only use '*.' as its first host label", origin)
+	}
+
+	return nil
+}
+
+func withDefault(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+
+	return values
+}
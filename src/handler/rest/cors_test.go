@@ -0,0 +1,152 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/gin-gonic/gin"
+)
+
+func Test_validateCORSOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		wantErr bool
+	}{
+		{name: "https", origin: "https://app.example.com"},
+		{name: "with port", origin: "http://localhost:3000"},
+		{name: "wildcard subdomain", origin: "https://*.example.com"},
+		{name: "missing scheme", origin: "app.example.com", wantErr: true},
+		{name: "other scheme", origin: "ftp://example.com", wantErr: true},
+		{name: "trailing slash", origin: "https://example.com/", wantErr: true},
+		{name: "path", origin: "https://example.com/app", wantErr: true},
+		{name: "wildcard not first", origin: "https://app.*.example.com", wantErr: true},
+		{name: "two wildcards", origin: "https://*.*.example.com", wantErr: true},
+		{name: "bare wildcard", origin: "*", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCORSOrigin(tt.origin); (err != nil) != tt.wantErr {
+				t.Errorf("validateCORSOrigin(%q) error = %v, wantErr %v", tt.origin, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_buildCORSConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    config.CORSConfig
+		wantErr bool
+	}{
+		{
+			name: "allowall",
+			conf: config.CORSConfig{Mode: corsModeAllowAll},
+		},
+		{
+			name:    "allowall with credentials",
+			conf:    config.CORSConfig{Mode: corsModeAllowAll, AllowCredentials: true},
+			wantErr: true,
+		},
+		{
+			name: "allowlist",
+			conf: config.CORSConfig{Mode: corsModeAllowList, AllowOrigins: []string{"https://*.example.com"}, AllowCredentials: true},
+		},
+		{
+			name:    "allowlist without origins",
+			conf:    config.CORSConfig{Mode: corsModeAllowList},
+			wantErr: true,
+		},
+		{
+			name:    "allowlist with an invalid origin",
+			conf:    config.CORSConfig{Mode: corsModeAllowList, AllowOrigins: []string{"https://example.com/"}},
+			wantErr: true,
+		},
+		{
+			name: "empty mode keeps the gin-contrib defaults",
+			conf: config.CORSConfig{AllowOrigins: []string{"https://example.com"}},
+		},
+		{
+			name:    "empty mode without origins",
+			conf:    config.CORSConfig{},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			conf:    config.CORSConfig{Mode: "default"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildCORSConfig(tt.conf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildCORSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.MaxAge != defaultCORSMaxAge {
+				t.Errorf("buildCORSConfig() MaxAge = %v, want the default %v", got.MaxAge, defaultCORSMaxAge)
+			}
+		})
+	}
+}
+
+func Test_rest_initCORS(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{
+		Env: "staging",
+		CORS: config.CORSConfig{
+			Mode:         corsModeAllowList,
+			AllowOrigins: []string{"https://app.example.com"},
+			Environments: map[string]config.CORSConfig{
+				"staging": {Mode: corsModeAllowList, AllowOrigins: []string{"https://*.staging.example.com"}},
+			},
+		},
+	})
+	r.http.Use(r.initCORS())
+	r.http.GET("/ping", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	tests := []struct {
+		name            string
+		origin          string
+		wantStatus      int
+		wantAllowOrigin string
+	}{
+		{
+			name:            "subdomain of the environment origin",
+			origin:          "https://web.staging.example.com",
+			wantStatus:      http.StatusOK,
+			wantAllowOrigin: "https://web.staging.example.com",
+		},
+		{
+			name:       "origin of the default policy is replaced",
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "no origin is not a cors request",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("initCORS() status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("initCORS() Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllowOrigin)
+			}
+			if tt.wantAllowOrigin != "" && rec.Header().Get("Access-Control-Expose-Headers") == "" {
+				t.Errorf("initCORS() exposes no header, want the defaults")
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/appcontext"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/header"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/utils/tracer"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDummyPath       string = "/dummy"
+	defaultDummyFixtureDir string = "./etc/dummy"
+
+	dummyLoadError     string = "Loading dummy fixtures error: %s"
+	dummyLoadSuccess   string = "Loaded %d dummy fixtures from %s"
+	dummyFixtureExt    string = ".json"
+	dummyParamPrefix   string = ":"
+	dummyWildcardStart string = "*"
+)
+
+type dummyFixture struct {
+	entity.DummyFixture
+	delay    time.Duration
+	segments []string
+}
+
+type dummyStore struct {
+	mu       sync.RWMutex
+	fixtures []dummyFixture
+}
+
+func (r *rest) registerDummyRoutes() {
+	if r.conf.Dummy.Enabled {
+		path := r.conf.Dummy.Path
+		if path == "" {
+			path = defaultDummyPath
+		}
+
+		r.dummy = &dummyStore{}
+		if err := r.loadDummyFixtures(); err != nil {
+			r.log.Fatal(context.Background(), fmt.Sprintf(dummyLoadError, err.Error()))
+		}
+
+		dummyAuth := gin.Accounts{
+			r.conf.Dummy.BasicAuth.Username: r.conf.Dummy.BasicAuth.Password,
+		}
+
+		r.http.GET(path, r.addFieldsToContext, r.dummyIndex)
+		r.http.POST(path, gin.BasicAuthForRealm(dummyAuth, "Restricted"), r.addFieldsToContext, r.dummyReload)
+		r.http.Any(fmt.Sprintf("%s/*any", path), r.addFieldsToContext, r.dummyServe)
+	}
+}
+
+// loadDummyFixtures reads every fixture file in the configured directory and
+// swaps them in at once, so a broken file never leaves a half loaded set behind
+func (r *rest) loadDummyFixtures() error {
+	dir := r.conf.Dummy.FixtureDir
+	if dir == "" {
+		dir = defaultDummyFixtureDir
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.NewWithCode(codes.CodeFilePathOpenFailed, "%s", err.Error())
+	}
+
+	fixtures := []dummyFixture{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != dummyFixtureExt {
+			continue
+		}
+
+		fixture, err := r.readDummyFixture(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		fixtures = append(fixtures, fixture)
+	}
+
+	r.dummy.mu.Lock()
+	r.dummy.fixtures = fixtures
+	r.dummy.mu.Unlock()
+
+	r.log.Info(context.Background(), fmt.Sprintf(dummyLoadSuccess, len(fixtures), dir))
+	return nil
+}
+
+func (r *rest) readDummyFixture(filename string) (dummyFixture, error) {
+	fixture := dummyFixture{}
+
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return fixture, errors.NewWithCode(codes.CodeFilePathOpenFailed, "%s", err.Error())
+	}
+
+	if err := r.json.Unmarshal(raw, &fixture.DummyFixture); err != nil {
+		return fixture, errors.NewWithCode(codes.CodeJSONUnmarshalError, "%s: %s", filename, err.Error())
+	}
+
+	if !strings.HasPrefix(fixture.Path, "/") {
+		return fixture, errors.NewWithCode(codes.CodeInvalidValue, "%s: path must start with '/'", filename)
+	}
+
+	if fixture.Delay != "" {
+		fixture.delay, err = time.ParseDuration(fixture.Delay)
+		if err != nil {
+			return fixture, errors.NewWithCode(codes.CodeInvalidValue, "%s: %s", filename, err.Error())
+		}
+	}
+
+	if fixture.Name == "" {
+		fixture.Name = strings.TrimSuffix(filepath.Base(filename), dummyFixtureExt)
+	}
+
+	if fixture.Method == "" {
+		fixture.Method = http.MethodGet
+	}
+	fixture.Method = strings.ToUpper(fixture.Method)
+
+	if fixture.Status == 0 {
+		fixture.Status = http.StatusOK
+	}
+
+	fixture.segments = splitDummyPath(fixture.Path)
+
+	return fixture, nil
+}
+
+func (r *rest) matchDummyFixture(method, path string) (dummyFixture, bool) {
+	r.dummy.mu.RLock()
+	defer r.dummy.mu.RUnlock()
+
+	segments := splitDummyPath(path)
+	for _, f := range r.dummy.fixtures {
+		if f.Method == method && matchDummySegments(f.segments, segments) {
+			return f, true
+		}
+	}
+
+	return dummyFixture{}, false
+}
+
+func splitDummyPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// matchDummySegments supports gin style ':param' and trailing '*wildcard' segments, a param
+// never matches an empty segment as in gin
+func matchDummySegments(pattern, segments []string) bool {
+	for i, p := range pattern {
+		if strings.HasPrefix(p, dummyWildcardStart) {
+			return true
+		}
+
+		if i >= len(segments) {
+			return false
+		}
+
+		if strings.HasPrefix(p, dummyParamPrefix) {
+			if segments[i] == "" {
+				return false
+			}
+		} else if p != segments[i] {
+			return false
+		}
+	}
+
+	return len(pattern) == len(segments)
+}
+
+func (r *rest) dummyIndex(ctx *gin.Context) {
+	r.dummy.mu.RLock()
+	fixtures := make([]entity.DummyFixture, 0, len(r.dummy.fixtures))
+	for _, f := range r.dummy.fixtures {
+		fixtures = append(fixtures, f.DummyFixture)
+	}
+	r.dummy.mu.RUnlock()
+
+	r.httpRespSuccess(ctx, codes.CodeSuccess, fixtures, nil)
+}
+
+func (r *rest) dummyReload(ctx *gin.Context) {
+	if err := r.loadDummyFixtures(); err != nil {
+		r.httpRespError(ctx, err)
+		return
+	}
+
+	r.dummyIndex(ctx)
+}
+
+func (r *rest) dummyServe(ctx *gin.Context) {
+	fixture, ok := r.matchDummyFixture(ctx.Request.Method, ctx.Param("any"))
+	if !ok {
+		r.httpRespError(ctx, errors.NewWithCode(codes.CodeNotFound, "no dummy fixture for %s %s", ctx.Request.Method, ctx.Param("any")))
+		return
+	}
+
+	cd, notAcceptable := r.responseCodec(ctx)
+	if notAcceptable {
+		r.httpRespError(ctx, errNotAcceptable(ctx.GetHeader(headerAccept)))
+		return
+	}
+
+	c := ctx.Request.Context()
+	if fixture.delay > 0 {
+		select {
+		case <-c.Done():
+			r.httpRespError(ctx, c.Err())
+			return
+		case <-time.After(fixture.delay):
+		}
+	}
+
+	resp := fixture.Response
+	resp.Meta = entity.Meta{
+		Path:       r.conf.Meta.Host + ctx.Request.URL.String(),
+		StatusCode: fixture.Status,
+		Status:     http.StatusText(fixture.Status),
+		Message:    fmt.Sprintf("%s %s [%d] %s", ctx.Request.Method, ctx.Request.URL.RequestURI(), fixture.Status, http.StatusText(fixture.Status)),
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Error:      fixture.Response.Meta.Error,
+		RequestID:  appcontext.GetRequestId(c),
+		TraceID:    tracer.TraceID(c),
+	}
+
+	reqstart := appcontext.GetRequestStartTime(c)
+	if !time.Time.IsZero(reqstart) {
+		resp.Meta.TimeElapsed = fmt.Sprintf("%dms", int64(time.Since(reqstart)/time.Millisecond))
+	}
+
+	raw, err := r.codecs.marshal(cd, &resp)
+	if err != nil {
+		r.httpRespError(ctx, errors.NewWithCode(codes.CodeInternalServerError, "%s", err.Error()))
+		return
+	}
+
+	ctx.Header(header.KeyRequestID, appcontext.GetRequestId(c))
+	ctx.Data(fixture.Status, cd.contentType, raw)
+}
@@ -0,0 +1,166 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+)
+
+// writeDummyFixtures writes every name to content pair as a file of dir
+func writeDummyFixtures(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("os.WriteFile() error = %v", err)
+		}
+	}
+}
+
+func Test_matchDummySegments(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		want    bool
+	}{
+		{name: "static", pattern: "/users", path: "/users", want: true},
+		{name: "trailing slash", pattern: "/users", path: "/users/", want: true},
+		{name: "static mismatch", pattern: "/users", path: "/orders", want: false},
+		{name: "param", pattern: "/users/:id", path: "/users/7", want: true},
+		{name: "param missing", pattern: "/users/:id", path: "/users", want: false},
+		{name: "param empty", pattern: "/users/:id/orders", path: "/users//orders", want: false},
+		{name: "longer path", pattern: "/users/:id", path: "/users/7/orders", want: false},
+		{name: "wildcard", pattern: "/files/*path", path: "/files/a/b.txt", want: true},
+		{name: "wildcard prefix mismatch", pattern: "/files/*path", path: "/images/a.png", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchDummySegments(splitDummyPath(tt.pattern), splitDummyPath(tt.path)); got != tt.want {
+				t.Errorf("matchDummySegments(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rest_loadDummyFixtures(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		wantErr   bool
+		wantNames []string
+	}{
+		{
+			name: "defaults filled in, other files ignored",
+			files: map[string]string{
+				"users.json": `{"path":"/users"}`,
+				"README.md":  `fixtures for the frontend`,
+			},
+			wantNames: []string{"users"},
+		},
+		{
+			name:    "broken json keeps the loaded fixtures",
+			files:   map[string]string{"broken.json": `{"path":`},
+			wantErr: true,
+		},
+		{
+			name:    "relative path",
+			files:   map[string]string{"relative.json": `{"path":"users"}`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid delay",
+			files:   map[string]string{"slow.json": `{"path":"/slow","delay":"soon"}`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeDummyFixtures(t, dir, tt.files)
+
+			r := newTestRest(t, config.GinConfig{Dummy: config.DummyConfig{FixtureDir: dir}})
+			loaded := []dummyFixture{{}}
+			r.dummy = &dummyStore{fixtures: loaded}
+
+			err := r.loadDummyFixtures()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadDummyFixtures() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(r.dummy.fixtures) != len(loaded) {
+					t.Errorf("loadDummyFixtures() replaced the fixtures after an error")
+				}
+				return
+			}
+
+			if len(r.dummy.fixtures) != len(tt.wantNames) {
+				t.Fatalf("loadDummyFixtures() loaded %d fixtures, want %d", len(r.dummy.fixtures), len(tt.wantNames))
+			}
+			for i, f := range r.dummy.fixtures {
+				if f.Name != tt.wantNames[i] || f.Method != http.MethodGet || f.Status != http.StatusOK {
+					t.Errorf("loadDummyFixtures() fixture = %+v, want %s with the default method and status", f.DummyFixture, tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_rest_dummyServe(t *testing.T) {
+	dir := t.TempDir()
+	writeDummyFixtures(t, dir, map[string]string{
+		"users.json":       `{"method":"get","path":"/users","response":{"data":[{"id":1}]}}`,
+		"user.json":        `{"path":"/users/:id","response":{"data":{"id":1}}}`,
+		"create-user.json": `{"method":"POST","path":"/users","status":201}`,
+		"files.json":       `{"path":"/files/*path"}`,
+	})
+
+	r := newTestRest(t, config.GinConfig{Dummy: config.DummyConfig{
+		Enabled:    true,
+		Path:       "/dummy",
+		FixtureDir: dir,
+		BasicAuth:  config.BasicAuthConf{Username: "dummy", Password: "secret"},
+	}})
+	r.registerDummyRoutes()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		accept     string
+		password   string
+		wantStatus int
+	}{
+		{name: "list", method: http.MethodGet, target: "/dummy/users", wantStatus: http.StatusOK},
+		{name: "param", method: http.MethodGet, target: "/dummy/users/7", wantStatus: http.StatusOK},
+		{name: "status from the fixture", method: http.MethodPost, target: "/dummy/users", wantStatus: http.StatusCreated},
+		{name: "wildcard", method: http.MethodGet, target: "/dummy/files/a/b.txt", wantStatus: http.StatusOK},
+		{name: "method without a fixture", method: http.MethodDelete, target: "/dummy/users/7", wantStatus: http.StatusNotFound},
+		{name: "path without a fixture", method: http.MethodGet, target: "/dummy/orders", wantStatus: http.StatusNotFound},
+		{name: "negotiated format", method: http.MethodGet, target: "/dummy/users", accept: "application/yaml", wantStatus: http.StatusOK},
+		{name: "format we do not encode", method: http.MethodGet, target: "/dummy/users", accept: "text/csv", wantStatus: http.StatusNotAcceptable},
+		{name: "reload without credentials", method: http.MethodPost, target: "/dummy", wantStatus: http.StatusUnauthorized},
+		{name: "reload with the wrong password", method: http.MethodPost, target: "/dummy", password: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "reload", method: http.MethodPost, target: "/dummy", password: "secret", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set(headerAccept, tt.accept)
+			}
+			if tt.password != "" {
+				req.SetBasicAuth("dummy", tt.password)
+			}
+
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("dummyServe() status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package rest
+
+import (
+	"github.com/downsized-devs/template-service-go/src/utils/fieldset"
+	"github.com/gin-gonic/gin"
+)
+
+const fieldsParam string = "fields"
+
+// selectFields projects data down to the sparse fieldset asked in ?fields=id,name,meta.status.
+// Paths are checked against the json tags of data, unknown ones are a bad request
+func (r *rest) selectFields(ctx *gin.Context, data interface{}) (interface{}, error) {
+	raw, ok := ctx.GetQuery(fieldsParam)
+	if !ok || raw == "" || data == nil {
+		return data, nil
+	}
+
+	set, err := fieldset.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return set.Project(data)
+}
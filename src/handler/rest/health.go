@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/downsized-devs/template-service-go/src/utils/health"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Liveness Check
+// @Description Reports the process is alive, dependencies are not checked
+// @Tags Server
+// @Produce json
+// @Success 200 {object} health.Report{}
+// @Router /healthz [GET]
+func (r *rest) Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, health.Report{Status: health.StatusUp})
+}
+
+// @Summary Readiness Check
+// @Description Runs every registered dependency check, fails as soon as shutdown begins
+// @Tags Server
+// @Produce json
+// @Success 200 {object} health.Report{}
+// @Failure 503 {object} health.Report{}
+// @Router /readyz [GET]
+func (r *rest) Readyz(ctx *gin.Context) {
+	report := r.health.Check(ctx.Request.Context())
+
+	status := http.StatusOK
+	if report.Status != health.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+
+	ctx.JSON(status, report)
+}
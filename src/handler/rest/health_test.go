@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/health"
+)
+
+func Test_rest_Readyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		check      health.CheckFunc
+		shutdown   bool
+		wantStatus int
+	}{
+		{
+			name:       "ready",
+			check:      func(ctx context.Context) error { return nil },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "dependency down",
+			check:      func(ctx context.Context) error { return fmt.Errorf("connection refused") },
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "shutting down",
+			check:      func(ctx context.Context) error { return nil },
+			shutdown:   true,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, config.GinConfig{})
+			r.health = health.Init(health.Config{}, r.log)
+			r.health.Register("sql_leader", tt.check)
+			if tt.shutdown {
+				r.health.Shutdown()
+			}
+			r.http.GET("/healthz", r.Healthz)
+			r.http.GET("/readyz", r.Readyz)
+
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Readyz() status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), `"latency"`) || strings.Contains(rec.Body.String(), "connection refused") {
+				t.Errorf("Readyz() body = %s, want latencies without errors", rec.Body.String())
+			}
+
+			// liveness never depends on the checks
+			rec = httptest.NewRecorder()
+			r.http.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("Healthz() status = %d, want %d", rec.Code, http.StatusOK)
+			}
+		})
+	}
+}
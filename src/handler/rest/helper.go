@@ -3,53 +3,115 @@ package rest
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 
+	firebase_auth "firebase.google.com/go/auth"
 	"github.com/downsized-devs/sdk-go/appcontext"
+	"github.com/downsized-devs/sdk-go/auth"
 	"github.com/downsized-devs/sdk-go/codes"
 	"github.com/downsized-devs/sdk-go/errors"
 	"github.com/downsized-devs/sdk-go/header"
+	"github.com/downsized-devs/sdk-go/language"
+	"github.com/downsized-devs/sdk-go/operator"
 	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/fielderror"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"github.com/downsized-devs/template-service-go/src/utils/queryspec"
+	"github.com/downsized-devs/template-service-go/src/utils/ratelimiter"
+	"github.com/downsized-devs/template-service-go/src/utils/tlsconfig"
+	"github.com/downsized-devs/template-service-go/src/utils/tracer"
+	"github.com/downsized-devs/template-service-go/src/utils/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func (r *rest) BodyLogger(ctx *gin.Context) {
-	if r.conf.LogRequest {
-		r.log.Info(ctx.Request.Context(),
-			fmt.Sprintf(infoRequest, ctx.Request.RequestURI, ctx.Request.Method))
+// trace middleware starts a server span per request, continuing the incoming W3C traceparent if any
+func (r *rest) StartTrace(ctx *gin.Context) {
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
 	}
 
+	c := r.tracer.Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+	c, span := r.tracer.Start(c, fmt.Sprintf("%s %s", ctx.Request.Method, route),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPMethod(ctx.Request.Method),
+			semconv.HTTPRoute(route),
+			semconv.HTTPTarget(ctx.Request.URL.RequestURI()),
+			semconv.HTTPClientIP(ctx.ClientIP()),
+			semconv.HTTPUserAgent(ctx.Request.UserAgent()),
+		),
+	)
+	defer span.End()
+
+	ctx.Request = ctx.Request.WithContext(c)
 	ctx.Next()
-	if r.conf.LogResponse {
-		if ctx.Writer.Status() < 300 {
-			r.log.Info(ctx.Request.Context(),
-				fmt.Sprintf(infoResponse, ctx.Request.RequestURI, ctx.Request.Method, ctx.Writer.Status()))
-		} else {
-			r.log.Error(ctx.Request.Context(),
-				fmt.Sprintf(infoResponse, ctx.Request.RequestURI, ctx.Request.Method, ctx.Writer.Status()))
-		}
+
+	tracer.SetStatus(span, ctx.Writer.Status(), nil)
+}
+
+// metrics middleware records request count, latency and in flight requests per route template
+func (r *rest) RecordMetrics(ctx *gin.Context) {
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
 	}
+
+	start := time.Now()
+	done := r.metrics.HTTPRequestInFlight(route, ctx.Request.Method)
+	ctx.Next()
+	done()
+
+	r.metrics.HTTPRequestObserve(route, ctx.Request.Method, ctx.Writer.Status(),
+		appcontext.GetAppResponseCode(ctx.Request.Context()), time.Since(start))
 }
 
-// timeout middleware wraps the request context with a timeout
-func (r *rest) SetTimeout(ctx *gin.Context) {
-	// wrap the request context with a timeout
-	c, cancel := context.WithTimeout(ctx.Request.Context(), r.conf.Timeout)
+// Recover turns a panic into the standard internal error response, logging its stack trace with the request context
+func (r *rest) Recover(ctx *gin.Context) {
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
 
-	// cancel to clear resources after finished
-	defer cancel()
+		// net/http uses this panic to abort a response on purpose
+		if rec == http.ErrAbortHandler {
+			panic(rec)
+		}
 
-	c = appcontext.SetRequestStartTime(c, time.Now())
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
 
-	// replace request with context wrapped request
-	ctx.Request = ctx.Request.WithContext(c)
-	ctx.Next()
+		c := ctx.Request.Context()
+		r.log.Error(c, fmt.Sprintf(panicRecovered, ctx.Request.Method, route, rec, debug.Stack()))
+		r.metrics.PanicRecovered(metrics.PanicSourceHTTP, route)
 
+		// part of the response is already out, the status can not be changed anymore
+		if ctx.Writer.Written() {
+			ctx.Abort()
+			return
+		}
+
+		r.httpRespError(ctx, errors.NewWithCode(codes.CodeInternalServerError, "recovered from panic"))
+	}()
+
+	ctx.Next()
 }
 
 func (r *rest) addFieldsToContext(ctx *gin.Context) {
@@ -69,6 +131,149 @@ func (r *rest) addFieldsToContext(ctx *gin.Context) {
 	ctx.Next()
 }
 
+// addClientIdentity stores the verified mTLS client certificate identity into the context
+func (r *rest) addClientIdentity(ctx *gin.Context) {
+	if ctx.Request.TLS != nil && len(ctx.Request.TLS.VerifiedChains) > 0 && len(ctx.Request.TLS.VerifiedChains[0]) > 0 {
+		identity := tlsconfig.NewClientIdentity(ctx.Request.TLS.VerifiedChains[0][0])
+		ctx.Request = ctx.Request.WithContext(tlsconfig.SetClientIdentity(ctx.Request.Context(), identity))
+	}
+
+	ctx.Next()
+}
+
+// VerifyUser validates the bearer token through auth and stores the authenticated user into the context
+func (r *rest) VerifyUser(ctx *gin.Context) {
+	token, err := getBearerToken(ctx.GetHeader(header.KeyAuthorization))
+	if err != nil {
+		r.httpRespError(ctx, err)
+		return
+	}
+
+	c := ctx.Request.Context()
+	fbToken, err := r.auth.VerifyToken(c, token)
+	if err != nil {
+		r.httpRespError(ctx, err)
+		return
+	}
+
+	c = appcontext.SetAuthToken(c, token)
+	c = r.auth.SetUserAuthInfo(c, auth.UserAuthParam{
+		User:          getUserFromToken(fbToken),
+		FirebaseToken: fbToken,
+	})
+	ctx.Request = ctx.Request.WithContext(c)
+	ctx.Next()
+}
+
+func getBearerToken(authorization string) (string, error) {
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, bearerScheme) || strings.TrimSpace(token) == "" {
+		return "", errors.NewWithCode(codes.CodeUnauthorized, "missing or malformed bearer token")
+	}
+
+	return strings.TrimSpace(token), nil
+}
+
+func getUserFromToken(token *firebase_auth.Token) auth.User {
+	user := auth.User{
+		UID: token.UID,
+	}
+
+	if email, ok := token.Claims["email"].(string); ok {
+		user.Email = email
+	}
+
+	if name, ok := token.Claims["name"].(string); ok {
+		user.Name = name
+	}
+
+	if phone, ok := token.Claims["phone_number"].(string); ok {
+		user.PhoneNumber = phone
+	}
+
+	// custom claims set on the firebase user, numbers are decoded as float64
+	if companyID, ok := token.Claims[claimCompanyID].(float64); ok {
+		user.CompanyID = int64(companyID)
+	}
+
+	if roleID, ok := token.Claims[claimRoleID].(float64); ok {
+		user.RoleID = int64(roleID)
+	}
+
+	return user
+}
+
+// Authorize returns a middleware rejecting users whose role is not granted the route action code
+func (r *rest) Authorize(param entity.Authorize) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		resource := ""
+		if scoped, _ := strconv.ParseBool(param.IsParam); scoped {
+			resource = ctx.Param(param.Param)
+		}
+
+		if err := r.uc.Permission.Authorize(ctx.Request.Context(), param, resource); err != nil {
+			r.httpRespError(ctx, err)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RateLimit returns a token bucket middleware for a route group, keyed by ip, user or api key as set in the rule
+func (r *rest) RateLimit(group string, rule config.RateLimitRule) gin.HandlerFunc {
+	if !r.conf.RateLimit.Enabled || rule.Limit < 1 || rule.Period <= 0 {
+		return func(ctx *gin.Context) { ctx.Next() }
+	}
+
+	rate := ratelimiter.Rate{Limit: rule.Limit, Period: rule.Period}
+	return func(ctx *gin.Context) {
+		c := ctx.Request.Context()
+		key := fmt.Sprintf("%s:%s", group, r.getRateLimitKey(ctx, rule.KeyBy))
+
+		res, err := r.ratelimiter.Take(c, key, rate)
+		if err != nil {
+			// fail open, an unavailable store should not take the whole api down
+			r.log.Error(c, err)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header(headerRateLimitLimit, strconv.FormatInt(res.Limit, 10))
+		ctx.Header(headerRateLimitRemaining, strconv.FormatInt(res.Remaining, 10))
+		ctx.Header(headerRateLimitReset, strconv.FormatInt(ceilSeconds(res.Reset), 10))
+
+		if !res.Allowed {
+			ctx.Header(headerRetryAfter, strconv.FormatInt(ceilSeconds(res.RetryAfter), 10))
+			r.httpRespError(ctx, errors.NewWithCode(codes.CodeTooManyRequest, "rate limit exceeded for %s", key))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func (r *rest) getRateLimitKey(ctx *gin.Context, keyBy string) string {
+	switch keyBy {
+	case rateLimitKeyUser:
+		if user, err := r.auth.GetUserAuthInfo(ctx.Request.Context()); err == nil && user.User.UID != "" {
+			return fmt.Sprintf("%s:%s", rateLimitKeyUser, user.User.UID)
+		}
+	case rateLimitKeyAPIKey:
+		if apiKey := ctx.GetHeader(r.conf.RateLimit.APIKeyHeader); apiKey != "" {
+			// never store the raw api key in the bucket store
+			sum := sha256.Sum256([]byte(apiKey))
+			return fmt.Sprintf("%s:%s", rateLimitKeyAPIKey, hex.EncodeToString(sum[:]))
+		}
+	}
+
+	return fmt.Sprintf("%s:%s", rateLimitKeyIP, ctx.ClientIP())
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
+
 func (r *rest) httpRespError(ctx *gin.Context, err error) {
 	c := ctx.Request.Context()
 
@@ -76,7 +281,67 @@ func (r *rest) httpRespError(ctx *gin.Context, err error) {
 		err = errors.NewWithCode(codes.CodeContextDeadlineExceeded, "%s", "Context Deadline Exceeded")
 	}
 
-	httpStatus, displayError := errors.Compile(err, appcontext.GetAcceptLanguage(ctx))
+	httpStatus, displayError, errResp := r.newErrorResp(ctx.Request, err)
+
+	r.log.Error(c, err)
+
+	c = appcontext.SetAppResponseCode(c, displayError.Code)
+	c = appcontext.SetAppErrorMessage(c, fmt.Sprintf("%s - %s", displayError.Title, displayError.Body))
+	c = appcontext.SetResponseHttpCode(c, httpStatus)
+	ctx.Request = ctx.Request.WithContext(c)
+
+	addVary(ctx.Writer.Header(), headerAccept)
+	contentType, raw := r.encodeError(ctx.Request, errResp)
+
+	ctx.Header(header.KeyRequestID, appcontext.GetRequestId(c))
+	ctx.Data(httpStatus, contentType, raw)
+	ctx.Abort()
+}
+
+// statusError answers with another http status than the one of the wrapped error code,
+// for statuses the sdk codes do not cover
+type statusError struct {
+	status int
+	err    error
+}
+
+func withStatus(err error, status int) error {
+	return &statusError{status: status, err: err}
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// newErrorResp builds the error envelope only from the request, so it is also safe to call
+// while a timed out handler still holds the gin context
+func (r *rest) newErrorResp(req *http.Request, err error) (int, errors.App, *entity.HTTPResp) {
+	c := req.Context()
+
+	// field errors wrap the coded error, the rejected fields are listed next to it
+	coded, fields, status := err, []entity.FieldError(nil), 0
+	if se, ok := err.(*statusError); ok {
+		coded, status = se.err, se.status
+	}
+
+	if fe, ok := fielderror.As(coded); ok {
+		coded = fe.Unwrap()
+		for _, f := range fe.Fields {
+			fields = append(fields, entity.FieldError{Field: f.Field, Rule: f.Rule, Param: f.Param, Message: f.Message})
+		}
+	}
+
+	lang := appcontext.GetAcceptLanguage(c)
+	httpStatus, displayError := errors.Compile(coded, lang)
+	if status != 0 {
+		httpStatus = status
+		// like the sdk messages, anything but Indonesian reads in English
+		displayError.Title = language.HTTPStatusText(operator.Ternary(lang == language.Indonesian, lang, language.English), status)
+	}
 	statusStr := http.StatusText(httpStatus)
 
 	errResp := &entity.HTTPResp{
@@ -85,32 +350,38 @@ func (r *rest) httpRespError(ctx *gin.Context, err error) {
 			Body:  displayError.Body,
 		},
 		Meta: entity.Meta{
-			Path:       r.conf.Meta.Host + ctx.Request.URL.String(),
+			Path:       r.conf.Meta.Host + req.URL.String(),
 			StatusCode: httpStatus,
 			Status:     statusStr,
-			Message:    fmt.Sprintf("%s %s [%d] %s", ctx.Request.Method, ctx.Request.URL.RequestURI(), httpStatus, statusStr),
+			Message:    fmt.Sprintf("%s %s [%d] %s", req.Method, req.URL.RequestURI(), httpStatus, statusStr),
 			Error: &entity.MetaError{
 				Code:    int(displayError.Code),
 				Message: err.Error(),
+				Fields:  fields,
 			},
 			Timestamp: time.Now().Format(time.RFC3339),
 			RequestID: appcontext.GetRequestId(c),
+			TraceID:   tracer.TraceID(c),
 		},
 	}
 
-	r.log.Error(c, err)
-
-	c = appcontext.SetAppResponseCode(c, displayError.Code)
-	c = appcontext.SetAppErrorMessage(c, fmt.Sprintf("%s - %s", displayError.Title, displayError.Body))
-	c = appcontext.SetResponseHttpCode(c, httpStatus)
-	ctx.Request = ctx.Request.WithContext(c)
-
-	ctx.Header(header.KeyRequestID, appcontext.GetRequestId(c))
-	ctx.AbortWithStatusJSON(httpStatus, errResp)
+	return httpStatus, displayError, errResp
 }
 
 func (r *rest) httpRespSuccess(ctx *gin.Context, code codes.Code, data interface{}, p *entity.Pagination) {
-	successApp := codes.Compile(code, appcontext.GetAcceptLanguage(ctx))
+	data, err := r.selectFields(ctx, data)
+	if err != nil {
+		r.httpRespError(ctx, err)
+		return
+	}
+
+	cd, notAcceptable := r.responseCodec(ctx)
+	if notAcceptable {
+		r.httpRespError(ctx, errNotAcceptable(ctx.GetHeader(headerAccept)))
+		return
+	}
+
+	successApp := codes.Compile(code, appcontext.GetAcceptLanguage(ctx.Request.Context()))
 	c := ctx.Request.Context()
 	meta := entity.Meta{
 		Path:       r.conf.Meta.Host + ctx.Request.URL.String(),
@@ -119,6 +390,7 @@ func (r *rest) httpRespSuccess(ctx *gin.Context, code codes.Code, data interface
 		Message:    fmt.Sprintf("%s %s [%d] %s", ctx.Request.Method, ctx.Request.URL.RequestURI(), successApp.StatusCode, http.StatusText(successApp.StatusCode)),
 		Timestamp:  time.Now().Format(time.RFC3339),
 		RequestID:  appcontext.GetRequestId(c),
+		TraceID:    tracer.TraceID(c),
 	}
 
 	resp := &entity.HTTPResp{
@@ -131,12 +403,35 @@ func (r *rest) httpRespSuccess(ctx *gin.Context, code codes.Code, data interface
 		Pagination: p,
 	}
 
+	// the message follows Accept-Language, caches must keep one copy per language
+	addVary(ctx.Writer.Header(), headerAcceptLanguage)
+
+	// the etag only covers data, pagination, the format and the message, meta changes on every response
+	if successApp.StatusCode == http.StatusOK && (ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead) {
+		payload, err := r.json.Marshal([]interface{}{data, p, cd.contentType, resp.Message})
+		if err != nil {
+			r.httpRespError(ctx, errors.NewWithCode(codes.CodeInternalServerError, "%s", err.Error()))
+			return
+		}
+
+		if checkNotModified(ctx, payload) {
+			c = appcontext.SetAppResponseCode(c, code)
+			c = appcontext.SetResponseHttpCode(c, http.StatusNotModified)
+			ctx.Request = ctx.Request.WithContext(c)
+
+			ctx.Header(header.KeyRequestID, appcontext.GetRequestId(c))
+			ctx.Status(http.StatusNotModified)
+			ctx.Writer.WriteHeaderNow()
+			return
+		}
+	}
+
 	reqstart := appcontext.GetRequestStartTime(c)
 	if !time.Time.IsZero(reqstart) {
 		resp.Meta.TimeElapsed = fmt.Sprintf("%dms", int64(time.Since(reqstart)/time.Millisecond))
 	}
 
-	raw, err := r.json.Marshal(&resp)
+	raw, err := r.codecs.marshal(cd, &resp)
 	if err != nil {
 		r.httpRespError(ctx, errors.NewWithCode(codes.CodeInternalServerError, "%s", err.Error()))
 		return
@@ -147,34 +442,85 @@ func (r *rest) httpRespSuccess(ctx *gin.Context, code codes.Code, data interface
 	ctx.Request = ctx.Request.WithContext(c)
 
 	ctx.Header(header.KeyRequestID, appcontext.GetRequestId(c))
-	ctx.Data(successApp.StatusCode, header.ContentTypeJSON, raw)
+	ctx.Data(successApp.StatusCode, cd.contentType, raw)
 }
 
-// Bind request body to struct using tag 'json'
+// Bind request body to struct using tag 'json', or 'form' for html forms. A body without a Content-Type
+// binds as gin always did, a body in a format that is not registered, e.g. xml, is rejected with a 415
 func (r *rest) Bind(ctx *gin.Context, obj interface{}) error {
-	err := ctx.ShouldBindWith(obj, binding.Default(ctx.Request.Method, ctx.ContentType()))
+	contentType := ctx.ContentType()
+
+	// yaml, msgpack and cbor bodies are turned into json first, so every format binds by the json tags
+	if cd, ok := r.codecs.byContentType(contentType); ok && cd.decode != nil {
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return errors.NewWithCode(codes.CodeBadRequest, "%s", err.Error())
+		}
+
+		raw, err := r.codecs.toJSON(cd, body)
+		if err != nil {
+			return errors.NewWithCode(codes.CodeBadRequest, "%s", err.Error())
+		}
+
+		if err := binding.JSON.BindBody(raw, obj); err != nil {
+			return r.bindError(ctx, obj, validation.TagJSON, err)
+		}
+
+		return nil
+	}
+
+	if contentType != "" && hasBody(ctx.Request) && !r.isBindable(contentType) {
+		return errUnsupportedMediaType(contentType)
+	}
+
+	b := binding.Default(ctx.Request.Method, contentType)
+	err := ctx.ShouldBindWith(obj, b)
 	if err != nil {
-		return errors.NewWithCode(codes.CodeBadRequest, "%s", err.Error())
+		tag := validation.TagJSON
+		if b == binding.Form || b == binding.FormMultipart {
+			tag = validation.TagForm
+		}
+		return r.bindError(ctx, obj, tag, err)
 	}
 
 	return nil
 }
 
+// isBindable accepts the registered formats and html forms
+func (r *rest) isBindable(contentType string) bool {
+	if _, ok := r.codecs.byContentType(contentType); ok {
+		return true
+	}
+
+	return contentType == binding.MIMEPOSTForm || contentType == binding.MIMEMultipartPOSTForm
+}
+
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody && (req.ContentLength != 0 || len(req.TransferEncoding) > 0)
+}
+
 // Bind all query params to struct using tag 'form'
 func (r *rest) BindQuery(ctx *gin.Context, obj interface{}) error {
 	err := ctx.ShouldBindWith(obj, binding.Query)
 	if err != nil {
-		return errors.NewWithCode(codes.CodeBadRequest, "%s", err.Error())
+		return r.bindError(ctx, obj, validation.TagForm, err)
 	}
 
 	return nil
 }
 
+// BindQuerySpec validates the sortBy and filter query params against the fields the handler declares,
+// the returned error lists every invalid field. Declare the schema with queryspec.MustSchema so a
+// mistake in it stops the service at startup
+func (r *rest) BindQuerySpec(ctx *gin.Context, schema queryspec.Schema) (queryspec.Spec, error) {
+	return schema.Parse(ctx.Request.URL.Query())
+}
+
 // Bind uri params to struct using tag 'uri'
 func (r *rest) BindUri(ctx *gin.Context, obj interface{}) error {
 	err := ctx.ShouldBindUri(obj)
 	if err != nil {
-		return errors.NewWithCode(codes.CodeBadRequest, "%s", err.Error())
+		return r.bindError(ctx, obj, validation.TagURI, err)
 	}
 
 	return nil
@@ -184,12 +530,12 @@ func (r *rest) BindUri(ctx *gin.Context, obj interface{}) error {
 func (r *rest) BindParams(ctx *gin.Context, obj interface{}) error {
 	err := r.BindQuery(ctx, obj)
 	if err != nil {
-		return errors.NewWithCode(codes.CodeBadRequest, "%s", err.Error())
+		return err
 	}
 
 	err = r.BindUri(ctx, obj)
 	if err != nil {
-		return errors.NewWithCode(codes.CodeBadRequest, "%s", err.Error())
+		return err
 	}
 
 	return nil
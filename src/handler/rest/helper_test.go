@@ -0,0 +1,351 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	firebase_auth "firebase.google.com/go/auth"
+	"github.com/downsized-devs/sdk-go/auth"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/header"
+	mock_auth "github.com/downsized-devs/sdk-go/tests/mock/auth"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	mock_sql "github.com/downsized-devs/sdk-go/tests/mock/sql"
+	permissionDom "github.com/downsized-devs/template-service-go/src/business/domain/permission"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/business/usecase"
+	"github.com/downsized-devs/template-service-go/src/business/usecase/permission"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/health"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"github.com/downsized-devs/template-service-go/src/utils/ratelimiter"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_rest_VerifyUser(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{})
+	authMock := mock_auth.NewMockInterface(gomock.NewController(t))
+	r.auth = authMock
+
+	token := &firebase_auth.Token{UID: "uid", Claims: map[string]interface{}{"email": "user@example.com", "name": "User"}}
+
+	tests := []struct {
+		name          string
+		authorization string
+		mockFunc      func()
+		wantStatus    int
+	}{
+		{
+			name:          "valid token",
+			authorization: "Bearer valid-token",
+			mockFunc: func() {
+				authMock.EXPECT().VerifyToken(gomock.Any(), "valid-token").Return(token, nil)
+				authMock.EXPECT().SetUserAuthInfo(gomock.Any(), auth.UserAuthParam{
+					User:          auth.User{UID: "uid", Email: "user@example.com", Name: "User"},
+					FirebaseToken: token,
+				}).DoAndReturn(func(ctx context.Context, param auth.UserAuthParam) context.Context { return ctx })
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "scheme is case insensitive",
+			authorization: "bearer  valid-token ",
+			mockFunc: func() {
+				authMock.EXPECT().VerifyToken(gomock.Any(), "valid-token").Return(token, nil)
+				authMock.EXPECT().SetUserAuthInfo(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, param auth.UserAuthParam) context.Context { return ctx })
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "missing header",
+			authorization: "",
+			mockFunc:      func() {},
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "other scheme",
+			authorization: "Basic dXNlcjpwYXNz",
+			mockFunc:      func() {},
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "invalid token",
+			authorization: "Bearer forged-token",
+			mockFunc: func() {
+				authMock.EXPECT().VerifyToken(gomock.Any(), "forged-token").Return(nil, errors.NewWithCode(codes.CodeAuthInvalidToken, "invalid token"))
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFunc()
+
+			rec := httptest.NewRecorder()
+			_, engine := gin.CreateTestContext(rec)
+			engine.GET("/private", r.VerifyUser, func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/private", nil)
+			if tt.authorization != "" {
+				req.Header.Set(header.KeyAuthorization, tt.authorization)
+			}
+			engine.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("VerifyUser() status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func Test_rest_RateLimit_authentication(t *testing.T) {
+	rule := config.RateLimitRule{Limit: 2, Period: time.Minute, KeyBy: rateLimitKeyIP}
+	r := newTestRest(t, config.GinConfig{RateLimit: config.RateLimitConfig{Enabled: true, Authentication: rule}})
+	r.ratelimiter = ratelimiter.Init(ratelimiter.Config{}, r.log, nil)
+	defer r.ratelimiter.Stop()
+	authMock := mock_auth.NewMockInterface(gomock.NewController(t))
+	r.auth = authMock
+
+	r.http.GET("/private", r.RateLimit(rateLimitGroupAuthentication, rule), r.VerifyUser, func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		mockFunc   func()
+		wantStatus int
+	}{
+		{
+			name: "first forged token is verified",
+			mockFunc: func() {
+				authMock.EXPECT().VerifyToken(gomock.Any(), "forged-token").Return(nil, errors.NewWithCode(codes.CodeAuthInvalidToken, "invalid token"))
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "second forged token is verified",
+			mockFunc: func() {
+				authMock.EXPECT().VerifyToken(gomock.Any(), "forged-token").Return(nil, errors.NewWithCode(codes.CodeAuthInvalidToken, "invalid token"))
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "limited before the auth provider is asked",
+			mockFunc:   func() {},
+			wantStatus: http.StatusTooManyRequests,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFunc()
+
+			req := httptest.NewRequest(http.MethodGet, "/private", nil)
+			req.Header.Set(header.KeyAuthorization, "Bearer forged-token")
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func Test_rest_Authorize(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{})
+	ctrl := gomock.NewController(t)
+	authMock := mock_auth.NewMockInterface(ctrl)
+	r.auth = authMock
+
+	// role 1 may only trigger the scheduler, and only through a route scoped to the task param
+	pd := permissionDom.Init(r.log, mock_sql.NewMockInterface(ctrl), config.PermissionConfig{
+		Roles: map[string][]string{"1": {entity.ActionCodeSchedulerTrigger}},
+	}, health.Init(health.Config{}, r.log))
+	r.uc = &usecase.Usecases{Permission: permission.Init(r.log, authMock, pd)}
+
+	tests := []struct {
+		name       string
+		param      entity.Authorize
+		wantStatus int
+	}{
+		{
+			name:       "granted",
+			param:      entity.Authorize{ActionCode: entity.ActionCodeSchedulerTrigger},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "granted on the uri resource",
+			param:      entity.Authorize{Param: "task", IsParam: "true", ActionCode: entity.ActionCodeSchedulerTrigger},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "denied",
+			param:      entity.Authorize{ActionCode: "order:delete"},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authMock.EXPECT().GetUserAuthInfo(gomock.Any()).Return(auth.UserAuthInfo{User: auth.User{RoleID: 1}}, nil)
+
+			rec := httptest.NewRecorder()
+			_, engine := gin.CreateTestContext(rec)
+			engine.POST("/tasks/:task", r.Authorize(tt.param), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tasks/cleanup", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Authorize() status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func Test_rest_Bind_contentType(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{})
+	r.initValidation()
+
+	type params struct {
+		Name string `json:"name" form:"name"`
+	}
+
+	type args struct {
+		target      string
+		contentType string
+		body        string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name:     "json",
+			args:     args{target: "/bind", contentType: header.ContentTypeJSON, body: `{"name":"json"}`},
+			wantName: "json",
+		},
+		{
+			name:     "missing content type falls back to the gin default",
+			args:     args{target: "/bind?name=query", body: `{"name":"json"}`},
+			wantName: "query",
+		},
+		{
+			name:    "unregistered content type",
+			args:    args{target: "/bind", contentType: "application/xml", body: `<name>xml</name>`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodPost, tt.args.target, bytes.NewBufferString(tt.args.body))
+			if tt.args.contentType != "" {
+				ctx.Request.Header.Set(header.KeyContentType, tt.args.contentType)
+			}
+
+			got := params{}
+			err := r.Bind(ctx, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Bind() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("Bind() name = %q, want %q", got.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func Test_rest_Recover(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     gin.HandlerFunc
+		wantPanic   bool
+		wantStatus  int
+		wantBody    string
+		wantCounted bool
+	}{
+		{
+			name:        "panic becomes the internal error envelope",
+			handler:     func(ctx *gin.Context) { panic("boom") },
+			wantStatus:  http.StatusInternalServerError,
+			wantCounted: true,
+		},
+		{
+			name: "panic after the response started keeps what was sent",
+			handler: func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, "partial")
+				panic("boom")
+			},
+			wantStatus:  http.StatusOK,
+			wantBody:    "partial",
+			wantCounted: true,
+		},
+		{
+			name:      "abort handler panic is passed on to net/http",
+			handler:   func(ctx *gin.Context) { panic(http.ErrAbortHandler) },
+			wantPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, config.GinConfig{})
+			r.metrics = metrics.Init(metrics.Config{Enabled: true})
+
+			var logged []string
+			logMock := mock_log.NewMockInterface(gomock.NewController(t))
+			logMock.EXPECT().Error(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, obj any) {
+				msg, _ := obj.(string)
+				logged = append(logged, msg)
+			}).AnyTimes()
+			r.log = logMock
+
+			r.http.Use(r.Recover)
+			r.http.GET("/v1/panic", tt.handler)
+
+			rec := httptest.NewRecorder()
+			panicked := func() (panicked bool) {
+				defer func() { panicked = recover() != nil }()
+				r.http.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/panic", nil))
+				return false
+			}()
+			if panicked != tt.wantPanic {
+				t.Fatalf("Recover() passed the panic on = %v, want %v", panicked, tt.wantPanic)
+			}
+			if tt.wantPanic {
+				return
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Recover() status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("Recover() body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.wantBody == "" {
+				var resp entity.HTTPResp
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Meta.Error == nil {
+					t.Fatalf("Recover() envelope = %s, error %v", rec.Body.String(), err)
+				}
+				if resp.Meta.StatusCode != http.StatusInternalServerError {
+					t.Errorf("Recover() meta status = %d, want %d", resp.Meta.StatusCode, http.StatusInternalServerError)
+				}
+			}
+
+			if len(logged) == 0 || !strings.HasPrefix(logged[0], "Recovered from panic on GET /v1/panic: boom") {
+				t.Errorf("Recover() logged %q, want the panic with its route", logged)
+			}
+
+			metricsRec := httptest.NewRecorder()
+			r.metrics.Handler().ServeHTTP(metricsRec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			if counted := strings.Contains(metricsRec.Body.String(), `panics_recovered_total{name="/v1/panic",source="http"} 1`); counted != tt.wantCounted {
+				t.Errorf("Recover() counted the panic = %v, want %v", counted, tt.wantCounted)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/appcontext"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/header"
+	"github.com/downsized-devs/template-service-go/src/utils/idempotency"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerIdempotencyKey      string = "Idempotency-Key"
+	headerIdempotencyReplayed string = "Idempotent-Replayed"
+
+	idempotencyAnonymous    string        = "anonymous"
+	idempotencyPollInterval time.Duration = 100 * time.Millisecond
+	idempotencyLockMargin   time.Duration = 10 * time.Second
+
+	defaultIdempotencyMaxBodySize int64 = 1 << 20
+)
+
+// idempotencyPerRequestHeaders are regenerated for every request and never replayed
+var idempotencyPerRequestHeaders = []string{
+	header.KeyRequestID,
+	headerRateLimitLimit,
+	headerRateLimitRemaining,
+	headerRateLimitReset,
+	headerRetryAfter,
+}
+
+// idempotencyTransportHeaders describe how the body travelled to the first client, e.g. compressed,
+// and are set again by the middlewares of the retry since the stored body is the unencoded one
+var idempotencyTransportHeaders = []string{
+	headerContentEncoding,
+	headerContentLength,
+}
+
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *idempotencyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent stores the first response of a mutating request carrying an Idempotency-Key
+// and replays it for retries of the same key, user and resource
+func (r *rest) Idempotent(ctx *gin.Context) {
+	key := ctx.GetHeader(headerIdempotencyKey)
+	if !r.conf.Idempotency.Enabled || key == "" || !isMutatingMethod(ctx.Request.Method) {
+		ctx.Next()
+		return
+	}
+
+	maxBodySize := r.conf.Idempotency.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultIdempotencyMaxBodySize
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBodySize))
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		r.httpRespError(ctx, errors.NewWithCode(codes.CodeFileTooBig, "request body is larger than %d bytes", maxBytesErr.Limit))
+		return
+	case err != nil:
+		r.httpRespError(ctx, errors.NewWithCode(codes.CodeBadRequest, "%s", err.Error()))
+		return
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	storeKey := r.getIdempotencyKey(ctx, key)
+	sum := sha256.Sum256(body)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	rec, claimed, err := r.beginIdempotent(ctx.Request.Context(), storeKey, fingerprint, r.idempotencyLock(ctx.FullPath()))
+	if err != nil {
+		r.httpRespError(ctx, err)
+		return
+	}
+
+	if !claimed {
+		r.replayIdempotent(ctx, rec.Response)
+		return
+	}
+
+	// the request context may already be cancelled or timed out, the outcome must still be stored
+	c := context.WithoutCancel(ctx.Request.Context())
+
+	// a panicking handler unwinds through here, the key is released before the panic carries on
+	// to Recover so a retry does not wait for the lock to run out
+	finished := false
+	defer func() {
+		if finished {
+			return
+		}
+		if err := r.idempotency.Release(c, storeKey, rec.Token); err != nil {
+			r.log.Error(c, err)
+		}
+	}()
+
+	w := &idempotencyWriter{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+	ctx.Writer = w
+	ctx.Next()
+	finished = true
+
+	if !isFinalStatus(w.Status()) {
+		if err := r.idempotency.Release(c, storeKey, rec.Token); err != nil {
+			r.log.Error(c, err)
+		}
+		return
+	}
+
+	resp := idempotency.Response{
+		StatusCode: w.Status(),
+		Header:     w.Header().Clone(),
+		Body:       w.body.Bytes(),
+	}
+	for _, h := range idempotencyPerRequestHeaders {
+		resp.Header.Del(h)
+	}
+	for _, h := range idempotencyTransportHeaders {
+		resp.Header.Del(h)
+	}
+
+	if err := r.idempotency.Complete(c, storeKey, rec.Token, resp); err != nil {
+		r.log.Error(c, err)
+	}
+}
+
+// beginIdempotent claims the key, waiting up to the configured time while a concurrent
+// request with the same key is still in progress
+func (r *rest) beginIdempotent(c context.Context, key, fingerprint string, lock time.Duration) (idempotency.Record, bool, error) {
+	deadline := time.Now().Add(r.conf.Idempotency.WaitTimeout)
+	for {
+		rec, claimed, err := r.idempotency.Begin(c, key, fingerprint, lock)
+		if err != nil || claimed {
+			return rec, claimed, err
+		}
+
+		if rec.Fingerprint != fingerprint {
+			return rec, false, errors.NewWithCode(codes.CodeBadRequest, "%s was already used with a different request body", headerIdempotencyKey)
+		}
+
+		if rec.Completed {
+			return rec, false, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return rec, false, errors.NewWithCode(codes.CodeConflict, "a request with the same %s is still in progress", headerIdempotencyKey)
+		}
+
+		select {
+		case <-c.Done():
+			return rec, false, errors.NewWithCode(codes.CodeContextCanceled, "%s", c.Err().Error())
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+func (r *rest) replayIdempotent(ctx *gin.Context, resp idempotency.Response) {
+	// records stored before the transport headers were left out may still carry them
+	for _, h := range idempotencyTransportHeaders {
+		resp.Header.Del(h)
+	}
+
+	// Vary is merged since the middlewares of the retry, like Compress, already set theirs
+	h := ctx.Writer.Header()
+	for k, v := range resp.Header {
+		if k != headerVary {
+			h[k] = v
+		}
+	}
+	for _, v := range resp.Header.Values(headerVary) {
+		for _, name := range strings.Split(v, ",") {
+			addVary(h, strings.TrimSpace(name))
+		}
+	}
+
+	c := appcontext.SetResponseHttpCode(ctx.Request.Context(), resp.StatusCode)
+	ctx.Request = ctx.Request.WithContext(c)
+
+	ctx.Header(header.KeyRequestID, appcontext.GetRequestId(c))
+	ctx.Header(headerIdempotencyReplayed, "true")
+	ctx.Status(resp.StatusCode)
+	ctx.Writer.Write(resp.Body)
+	ctx.Abort()
+}
+
+// idempotencyLock keeps the claim for as long as the route may run, so a retry never takes over a
+// request that is still within its deadline. Routes without a deadline use the store LockTimeout
+func (r *rest) idempotencyLock(route string) time.Duration {
+	timeout := r.routeTimeout(route)
+	if timeout <= 0 {
+		return 0
+	}
+
+	return timeout + idempotencyLockMargin
+}
+
+// getIdempotencyKey scopes the client key to the user and the requested resource, hashed to a fixed
+// length. The path is taken as sent so the same key on /orders/1 and /orders/2 stays apart
+func (r *rest) getIdempotencyKey(ctx *gin.Context, key string) string {
+	user := idempotencyAnonymous
+	if u, err := r.auth.GetUserAuthInfo(ctx.Request.Context()); err == nil && u.User.UID != "" {
+		user = u.User.UID
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s %s:%s", user, ctx.Request.Method, ctx.Request.URL.Path, key)))
+	return hex.EncodeToString(sum[:])
+}
+
+// isFinalStatus reports whether a retry would get the same answer, a success or a rejection of
+// the request itself. Failures, rejections by the rate limiter or the authorization running around
+// this middleware and conflicts with other requests may clear up, so the key is released instead
+func isFinalStatus(status int) bool {
+	switch {
+	case status >= http.StatusOK && status < http.StatusMultipleChoices:
+		return true
+	case status < http.StatusBadRequest || status >= http.StatusInternalServerError:
+		return false
+	}
+
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout,
+		http.StatusConflict, http.StatusTooEarly, http.StatusTooManyRequests:
+		return false
+	default:
+		return true
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,365 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/idempotency"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+)
+
+func Test_rest_Idempotent_compressedReplay(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{
+		Idempotency: config.IdempotencyConfig{Enabled: true, WaitTimeout: time.Second},
+		Compression: config.CompressionConfig{Enabled: true, Encodings: []string{encodingGzip}},
+	})
+	r.idempotency = idempotency.Init(idempotency.Config{}, r.log, r.json, nil)
+	defer r.idempotency.Stop()
+
+	calls := 0
+	payload := strings.Repeat("idempotent ", 200)
+	r.http.Use(r.Compress)
+	r.http.POST("/orders", r.Idempotent, func(ctx *gin.Context) {
+		calls++
+		r.httpRespSuccess(ctx, codes.CodeSuccess, payload, nil)
+	})
+
+	type args struct {
+		acceptEncoding string
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantEncoding string
+		wantReplayed string
+	}{
+		{
+			name:         "first request gzip",
+			args:         args{acceptEncoding: encodingGzip},
+			wantEncoding: encodingGzip,
+		},
+		{
+			name:         "retry gzip",
+			args:         args{acceptEncoding: encodingGzip},
+			wantEncoding: encodingGzip,
+			wantReplayed: "true",
+		},
+		{
+			name:         "retry identity",
+			args:         args{acceptEncoding: "identity"},
+			wantEncoding: "",
+			wantReplayed: "true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(`{}`))
+			req.Header.Set(headerIdempotencyKey, "order-1")
+			req.Header.Set(headerAcceptEncoding, tt.args.acceptEncoding)
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get(headerContentEncoding); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := rec.Header().Get(headerIdempotencyReplayed); got != tt.wantReplayed {
+				t.Errorf("%s = %q, want %q", headerIdempotencyReplayed, got, tt.wantReplayed)
+			}
+			if got, want := strings.Join(rec.Header().Values(headerVary), ", "), headerAcceptEncoding+", "+headerAccept+", "+headerAcceptLanguage; got != want {
+				t.Errorf("Vary = %q, want %q", got, want)
+			}
+
+			body := rec.Body.Bytes()
+			if tt.wantEncoding == encodingGzip {
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader() error = %v", err)
+				}
+				if body, err = io.ReadAll(zr); err != nil {
+					t.Fatalf("reading gzip body error = %v", err)
+				}
+			}
+			if !bytes.Contains(body, []byte(payload)) {
+				t.Errorf("body does not contain the payload, got %.80q", body)
+			}
+		})
+	}
+
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func Test_rest_Idempotent_transientStatus(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{
+		Idempotency: config.IdempotencyConfig{Enabled: true, WaitTimeout: time.Second},
+	})
+	r.idempotency = idempotency.Init(idempotency.Config{}, r.log, r.json, nil)
+	defer r.idempotency.Stop()
+
+	// the first attempt is turned away like the rate limiter would, the retry goes through
+	statuses := []int{http.StatusTooManyRequests, http.StatusCreated}
+	calls := 0
+	r.http.POST("/orders", r.Idempotent, func(ctx *gin.Context) {
+		ctx.String(statuses[calls], "attempt %d", calls)
+		calls++
+	})
+
+	tests := []struct {
+		name         string
+		wantStatus   int
+		wantBody     string
+		wantReplayed string
+	}{
+		{
+			name:       "rate limited",
+			wantStatus: http.StatusTooManyRequests,
+			wantBody:   "attempt 0",
+		},
+		{
+			name:       "retry runs again",
+			wantStatus: http.StatusCreated,
+			wantBody:   "attempt 1",
+		},
+		{
+			name:         "retry replays the success",
+			wantStatus:   http.StatusCreated,
+			wantBody:     "attempt 1",
+			wantReplayed: "true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(`{}`))
+			req.Header.Set(headerIdempotencyKey, "order-2")
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if got := rec.Header().Get(headerIdempotencyReplayed); got != tt.wantReplayed {
+				t.Errorf("%s = %q, want %q", headerIdempotencyReplayed, got, tt.wantReplayed)
+			}
+		})
+	}
+}
+
+func Test_rest_Idempotent_resource(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{
+		Idempotency: config.IdempotencyConfig{Enabled: true, WaitTimeout: time.Second},
+	})
+	r.idempotency = idempotency.Init(idempotency.Config{}, r.log, r.json, nil)
+	defer r.idempotency.Stop()
+
+	r.http.PUT("/orders/:id", r.Idempotent, func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "order %s", ctx.Param("id"))
+	})
+
+	tests := []struct {
+		name         string
+		path         string
+		wantBody     string
+		wantReplayed string
+	}{
+		{
+			name:     "first order",
+			path:     "/orders/1",
+			wantBody: "order 1",
+		},
+		{
+			name:     "same key on another order",
+			path:     "/orders/2",
+			wantBody: "order 2",
+		},
+		{
+			name:         "retry of the first order",
+			path:         "/orders/1",
+			wantBody:     "order 1",
+			wantReplayed: "true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, tt.path, bytes.NewBufferString(`{}`))
+			req.Header.Set(headerIdempotencyKey, "order-3")
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if got := rec.Header().Get(headerIdempotencyReplayed); got != tt.wantReplayed {
+				t.Errorf("%s = %q, want %q", headerIdempotencyReplayed, got, tt.wantReplayed)
+			}
+		})
+	}
+}
+
+func Test_rest_Idempotent_bodySize(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{
+		Idempotency: config.IdempotencyConfig{Enabled: true, WaitTimeout: time.Second, MaxBodySize: 16},
+	})
+	r.idempotency = idempotency.Init(idempotency.Config{}, r.log, r.json, nil)
+	defer r.idempotency.Stop()
+
+	calls := 0
+	r.http.POST("/orders", r.Idempotent, func(ctx *gin.Context) {
+		calls++
+		ctx.String(http.StatusCreated, "created")
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCalls  int
+	}{
+		{
+			name:       "within the limit",
+			body:       `{"id":1}`,
+			wantStatus: http.StatusCreated,
+			wantCalls:  1,
+		},
+		{
+			name:       "over the limit",
+			body:       `{"id":1,"note":"too long"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCalls:  0,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(tt.body))
+			req.Header.Set(headerIdempotencyKey, fmt.Sprintf("order-size-%d", i))
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func Test_rest_Idempotent_panic(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{
+		Idempotency: config.IdempotencyConfig{Enabled: true, WaitTimeout: time.Second},
+	})
+	r.metrics = metrics.Init(metrics.Config{})
+	r.idempotency = idempotency.Init(idempotency.Config{}, r.log, r.json, nil)
+	defer r.idempotency.Stop()
+
+	calls := 0
+	r.http.POST("/orders", r.Recover, r.Idempotent, func(ctx *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("nil pointer")
+		}
+		ctx.String(http.StatusCreated, "attempt %d", calls)
+	})
+
+	tests := []struct {
+		name       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "handler panics",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "retry runs again",
+			wantStatus: http.StatusCreated,
+			wantBody:   "attempt 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(`{}`))
+			req.Header.Set(headerIdempotencyKey, "order-panic")
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func Test_isFinalStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   bool
+	}{
+		{name: "created", status: http.StatusCreated, want: true},
+		{name: "no content", status: http.StatusNoContent, want: true},
+		{name: "redirect", status: http.StatusSeeOther, want: false},
+		{name: "bad request", status: http.StatusBadRequest, want: true},
+		{name: "not found", status: http.StatusNotFound, want: true},
+		{name: "unprocessable", status: http.StatusUnprocessableEntity, want: true},
+		{name: "unauthorized", status: http.StatusUnauthorized, want: false},
+		{name: "forbidden", status: http.StatusForbidden, want: false},
+		{name: "request timeout", status: http.StatusRequestTimeout, want: false},
+		{name: "conflict", status: http.StatusConflict, want: false},
+		{name: "too many requests", status: http.StatusTooManyRequests, want: false},
+		{name: "server error", status: http.StatusInternalServerError, want: false},
+		{name: "gateway timeout", status: http.StatusGatewayTimeout, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFinalStatus(tt.status); got != tt.want {
+				t.Errorf("isFinalStatus(%d) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rest_idempotencyLock(t *testing.T) {
+	r := newTestRest(t, config.GinConfig{
+		Timeout: 60 * time.Second,
+		RouteTimeouts: map[string]time.Duration{
+			"/v1/admin/":                 120 * time.Second,
+			"/v1/admin/scheduler/events": 0,
+		},
+	})
+
+	tests := []struct {
+		name  string
+		route string
+		want  time.Duration
+	}{
+		{name: "global timeout", route: "/v1/orders", want: 60*time.Second + idempotencyLockMargin},
+		{name: "longer route timeout", route: "/v1/admin/scheduler/trigger", want: 120*time.Second + idempotencyLockMargin},
+		{name: "route without a deadline", route: "/v1/admin/scheduler/events", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.idempotencyLock(tt.route); got != tt.want {
+				t.Errorf("idempotencyLock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
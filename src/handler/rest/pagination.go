@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/utils/cursor"
+	"github.com/gin-gonic/gin"
+)
+
+// BindCursor binds the cursor and limit query params for a keyset listing ordered by keys.
+// The limit is clamped to the configured page size and the cursor is rejected with a bad
+// request when it was tampered with or issued for another ordering
+func (r *rest) BindCursor(ctx *gin.Context, keys []cursor.Key) (entity.CursorParam, error) {
+	param := entity.CursorParam{}
+	if err := r.BindQuery(ctx, &param); err != nil {
+		return param, err
+	}
+
+	param.Limit = r.cursor.Limit(param.Limit)
+
+	if param.Cursor == "" {
+		return param, nil
+	}
+
+	after, err := r.cursor.Decode(keys, param.Cursor)
+	if err != nil {
+		return param, err
+	}
+	param.After = after
+
+	return param, nil
+}
+
+// CursorPagination builds the pagination of a keyset page. Fetch Limit + 1 rows, hasMore tells
+// whether the extra row came back and last holds the sort key values of the last returned row
+func (r *rest) CursorPagination(param entity.CursorParam, keys []cursor.Key, count int64, hasMore bool, last []interface{}) (*entity.Pagination, error) {
+	next := ""
+	if hasMore {
+		encoded, err := r.cursor.Encode(keys, last)
+		if err != nil {
+			return nil, err
+		}
+		next = encoded
+	}
+
+	p := &entity.Pagination{}
+	p.ProcessCursorPagination(param, count, next)
+
+	return p, nil
+}
@@ -0,0 +1,182 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/downsized-devs/sdk-go/appcontext"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/language"
+	"github.com/downsized-devs/sdk-go/operator"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	problemModeEnvelope string = "envelope"
+	problemModeProblem  string = "problem"
+
+	mediaProblemJSON   string = "application/problem+json"
+	problemBlankType   string = "about:blank"
+	defaultProblemPath string = "/problems"
+)
+
+// wantsProblem reports whether errors go out as problem documents, either because the service
+// is configured so or because the client prefers application/problem+json over our formats
+func (r *rest) wantsProblem(accept string) bool {
+	if r.conf.Problem.Mode == problemModeProblem {
+		return true
+	}
+
+	ranges := parseAccept(accept)
+
+	q := 0.0
+	for _, rg := range ranges {
+		if rg.mediaType == mediaProblemJSON {
+			q = rg.q
+		}
+	}
+
+	if q == 0 {
+		return false
+	}
+
+	cd, ok := r.codecs.negotiate(accept)
+	return !ok || q >= cd.quality(ranges)
+}
+
+// encodeError renders the error envelope in the negotiated format, or as a problem document
+func (r *rest) encodeError(req *http.Request, errResp *entity.HTTPResp) (string, []byte) {
+	accept := req.Header.Get(headerAccept)
+
+	if r.wantsProblem(accept) {
+		raw, err := r.json.Marshal(r.newProblem(req, errResp))
+		if err == nil {
+			return mediaProblemJSON, raw
+		}
+		r.log.Error(req.Context(), err)
+	}
+
+	// an error envelope is always sent, in json when the client accepts nothing we can encode
+	cd, ok := r.codecs.negotiate(accept)
+	if !ok {
+		cd = r.codecs.list[0]
+	}
+
+	raw, err := r.codecs.marshal(cd, errResp)
+	if err != nil {
+		r.log.Error(req.Context(), err)
+		cd = r.codecs.list[0]
+		raw, _ = r.json.Marshal(errResp)
+	}
+
+	return cd.contentType, raw
+}
+
+func (r *rest) newProblem(req *http.Request, errResp *entity.HTTPResp) *entity.Problem {
+	problem := &entity.Problem{
+		Type:      problemBlankType,
+		Title:     errResp.Message.Title,
+		Status:    errResp.Meta.StatusCode,
+		Detail:    errResp.Message.Body,
+		Instance:  req.URL.RequestURI(),
+		Timestamp: errResp.Meta.Timestamp,
+		RequestID: errResp.Meta.RequestID,
+		TraceID:   errResp.Meta.TraceID,
+	}
+
+	if metaErr := errResp.Meta.Error; metaErr != nil {
+		problem.Code = metaErr.Code
+		problem.Message = metaErr.Message
+		problem.Fields = metaErr.Fields
+
+		// a status the code does not map to, like a 406, is only described by the status itself
+		if msg, ok := codes.ErrorMessages[codes.Code(metaErr.Code)]; ok && msg.StatusCode == problem.Status {
+			problem.Type = r.problemTypeURI(codes.Code(metaErr.Code))
+		}
+	}
+
+	return problem
+}
+
+func (r *rest) problemTypeURI(code codes.Code) string {
+	base := strings.TrimSuffix(r.conf.Problem.TypeBaseURI, "/")
+	if base == "" {
+		base = defaultProblemPath
+	}
+
+	return fmt.Sprintf("%s/%d", base, code)
+}
+
+func (r *rest) registerProblemRoutes() {
+	switch r.conf.Problem.Mode {
+	case "", problemModeEnvelope, problemModeProblem:
+	default:
+		r.log.Fatal(context.Background(), fmt.Sprintf("Unsupported error response mode %q", r.conf.Problem.Mode))
+	}
+
+	problems := r.http.Group(defaultProblemPath, r.addFieldsToContext)
+	problems.GET("", r.ListProblemTypes)
+	problems.GET("/:code", r.GetProblemType)
+}
+
+// @Summary List Problem Types
+// @Description Lists the RFC 7807 problem type URIs, one per error code. Error responses are
+// @Description problem documents when the client accepts application/problem+json or the service
+// @Description is configured so, their type member points at one of these or is about:blank
+// @Tags Server
+// @Produce json
+// @Success 200 {object} entity.HTTPResp{data=[]entity.ProblemType{}}
+// @Failure default {object} entity.Problem{}
+// @Router /problems [GET]
+func (r *rest) ListProblemTypes(ctx *gin.Context) {
+	lang := appcontext.GetAcceptLanguage(ctx.Request.Context())
+
+	types := make([]entity.ProblemType, 0, len(codes.ErrorMessages))
+	for code, msg := range codes.ErrorMessages {
+		types = append(types, r.newProblemType(code, msg, lang))
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Code < types[j].Code })
+
+	r.httpRespSuccess(ctx, codes.CodeSuccess, types, nil)
+}
+
+// @Summary Get Problem Type
+// @Description Describes the problem type of an error code, the target of a problem document type URI
+// @Tags Server
+// @Param code path int true "Error code"
+// @Produce json
+// @Success 200 {object} entity.HTTPResp{data=entity.ProblemType{}}
+// @Failure 404 {object} entity.HTTPResp{}
+// @Router /problems/{code} [GET]
+func (r *rest) GetProblemType(ctx *gin.Context) {
+	code, err := strconv.ParseUint(ctx.Param("code"), 10, 32)
+	if err != nil {
+		r.httpRespError(ctx, errors.NewWithCode(codes.CodeNotFound, "unknown problem type %q", ctx.Param("code")))
+		return
+	}
+
+	msg, ok := codes.ErrorMessages[codes.Code(code)]
+	if !ok {
+		r.httpRespError(ctx, errors.NewWithCode(codes.CodeNotFound, "unknown problem type %d", code))
+		return
+	}
+
+	lang := appcontext.GetAcceptLanguage(ctx.Request.Context())
+	r.httpRespSuccess(ctx, codes.CodeSuccess, r.newProblemType(codes.Code(code), msg, lang), nil)
+}
+
+func (r *rest) newProblemType(code codes.Code, msg codes.Message, lang string) entity.ProblemType {
+	return entity.ProblemType{
+		Type:   r.problemTypeURI(code),
+		Code:   int(code),
+		Status: msg.StatusCode,
+		Title:  operator.Ternary(lang == language.Indonesian, msg.TitleID, msg.TitleEN),
+		Detail: operator.Ternary(lang == language.Indonesian, msg.BodyID, msg.BodyEN),
+	}
+}
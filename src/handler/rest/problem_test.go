@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/gin-gonic/gin"
+)
+
+func Test_rest_httpRespError_problem(t *testing.T) {
+	tests := []struct {
+		name            string
+		conf            config.ProblemConfig
+		accept          string
+		wantContentType string
+		wantType        string
+	}{
+		{
+			name:            "envelope by default",
+			accept:          "application/json",
+			wantContentType: "application/json",
+		},
+		{
+			name:            "client asks for a problem document",
+			accept:          "application/problem+json",
+			wantContentType: mediaProblemJSON,
+			wantType:        "/problems/" + fmt.Sprint(codes.CodeNotFound),
+		},
+		{
+			name:            "client prefers the envelope",
+			accept:          "application/json, application/problem+json;q=0.5",
+			wantContentType: "application/json",
+		},
+		{
+			name:            "client prefers the problem document",
+			accept:          "application/json;q=0.5, application/problem+json",
+			wantContentType: mediaProblemJSON,
+			wantType:        "/problems/" + fmt.Sprint(codes.CodeNotFound),
+		},
+		{
+			name:            "client accepts nothing we encode",
+			accept:          "text/csv",
+			wantContentType: "application/json",
+		},
+		{
+			name:            "problem mode",
+			conf:            config.ProblemConfig{Mode: problemModeProblem, TypeBaseURI: "https://errors.example.com/"},
+			accept:          "application/json",
+			wantContentType: mediaProblemJSON,
+			wantType:        "https://errors.example.com/" + fmt.Sprint(codes.CodeNotFound),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, config.GinConfig{Problem: tt.conf})
+			r.http.GET("/v1/tasks/:id", func(ctx *gin.Context) {
+				r.httpRespError(ctx, errors.NewWithCode(codes.CodeNotFound, "task %s not found", ctx.Param("id")))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/tasks/7?verbose=1", nil)
+			req.Header.Set(headerAccept, tt.accept)
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusNotFound {
+				t.Fatalf("httpRespError() status = %d, want %d", rec.Code, http.StatusNotFound)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Fatalf("httpRespError() content type = %q, want %q", got, tt.wantContentType)
+			}
+
+			if tt.wantContentType != mediaProblemJSON {
+				var resp entity.HTTPResp
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Meta.Error == nil {
+					t.Errorf("httpRespError() envelope = %s, error %v", rec.Body.String(), err)
+				}
+				return
+			}
+
+			var problem entity.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("httpRespError() problem = %s, error %v", rec.Body.String(), err)
+			}
+			if problem.Type != tt.wantType {
+				t.Errorf("problem type = %q, want %q", problem.Type, tt.wantType)
+			}
+			if problem.Status != http.StatusNotFound || problem.Code != int(codes.CodeNotFound) {
+				t.Errorf("problem status = %d code = %d, want %d and %d", problem.Status, problem.Code, http.StatusNotFound, codes.CodeNotFound)
+			}
+			if problem.Instance != "/v1/tasks/7?verbose=1" || problem.Message != "task 7 not found" {
+				t.Errorf("problem instance = %q message = %q", problem.Instance, problem.Message)
+			}
+		})
+	}
+}
+
+func Test_rest_GetProblemType(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       string
+		wantStatus int
+	}{
+		{
+			name:       "known code",
+			code:       fmt.Sprint(codes.CodeNotFound),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unknown code",
+			code:       "999999",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "not a code",
+			code:       "not-found",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, config.GinConfig{})
+			r.http.GET("/problems/:code", r.GetProblemType)
+
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/problems/"+tt.code, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("GetProblemType() status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data entity.ProblemType `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("GetProblemType() body = %s, error %v", rec.Body.String(), err)
+			}
+			if resp.Data.Type != "/problems/"+tt.code || resp.Data.Status != http.StatusNotFound {
+				t.Errorf("GetProblemType() = %+v", resp.Data)
+			}
+		})
+	}
+}
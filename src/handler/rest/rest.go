@@ -4,30 +4,63 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/pprof"
 	"os/signal"
+	"runtime"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/downsized-devs/sdk-go/appcontext"
 	"github.com/downsized-devs/sdk-go/auth"
+	"github.com/downsized-devs/sdk-go/codes"
 	"github.com/downsized-devs/sdk-go/configreader"
+	"github.com/downsized-devs/sdk-go/errors"
 	"github.com/downsized-devs/sdk-go/logger"
 	"github.com/downsized-devs/sdk-go/parser"
 	"github.com/downsized-devs/template-service-go/docs/swagger"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
 	"github.com/downsized-devs/template-service-go/src/business/usecase"
 	"github.com/downsized-devs/template-service-go/src/handler/scheduler"
+	"github.com/downsized-devs/template-service-go/src/utils/accesslog"
 	"github.com/downsized-devs/template-service-go/src/utils/config"
-	"github.com/gin-contrib/cors"
+	"github.com/downsized-devs/template-service-go/src/utils/cursor"
+	"github.com/downsized-devs/template-service-go/src/utils/health"
+	"github.com/downsized-devs/template-service-go/src/utils/idempotency"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"github.com/downsized-devs/template-service-go/src/utils/ratelimiter"
+	"github.com/downsized-devs/template-service-go/src/utils/tlsconfig"
+	"github.com/downsized-devs/template-service-go/src/utils/tracer"
+	"github.com/downsized-devs/template-service-go/src/utils/validation"
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"gopkg.in/yaml.v2"
 )
 
 const (
-	infoRequest  string = `httpclient Sent Request: uri=%v method=%v`
-	infoResponse string = `httpclient Received Response: uri=%v method=%v resp_code=%v`
+	panicRecovered string = "Recovered from panic on %s %s: %v\n%s"
+
+	bearerScheme   string = "Bearer"
+	claimCompanyID string = "company_id"
+	claimRoleID    string = "role_id"
+
+	headerRateLimitLimit     string = "RateLimit-Limit"
+	headerRateLimitRemaining string = "RateLimit-Remaining"
+	headerRateLimitReset     string = "RateLimit-Reset"
+	headerRetryAfter         string = "Retry-After"
+
+	rateLimitKeyIP     string = "ip"
+	rateLimitKeyUser   string = "user"
+	rateLimitKeyAPIKey string = "apikey"
+
+	rateLimitGroupPublic         string = "public"
+	rateLimitGroupAuthentication string = "authentication"
+	rateLimitGroupPrivate        string = "private"
+	rateLimitGroupAdmin          string = "admin"
+
+	defaultPprofPathPrefix string = "/debug/pprof"
+	defaultMetricsPath     string = "/metrics"
+	unmatchedRoute         string = "unmatched"
 )
 
 var once = &sync.Once{}
@@ -45,6 +78,19 @@ type rest struct {
 	log          logger.Interface
 	uc           *usecase.Usecases
 	scheduler    scheduler.Interface
+	metrics      metrics.Interface
+	tracer       tracer.Interface
+	ratelimiter  ratelimiter.Interface
+	idempotency  idempotency.Interface
+	accesslog    accesslog.Interface
+	health       health.Interface
+	tls          tlsconfig.Interface
+	cursor       cursor.Interface
+	codecs       *codecs
+	validation   validation.Interface
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+	dummy        *dummyStore
 }
 
 type InitParam struct {
@@ -55,6 +101,14 @@ type InitParam struct {
 	Json         parser.JsonInterface
 	Uc           *usecase.Usecases
 	Scheduler    scheduler.Interface
+	Metrics      metrics.Interface
+	Tracer       tracer.Interface
+	RateLimiter  ratelimiter.Interface
+	Idempotency  idempotency.Interface
+	AccessLog    accesslog.Interface
+	Health       health.Interface
+	TLS          tlsconfig.Interface
+	Cursor       cursor.Interface
 }
 
 func Init(params InitParam) REST {
@@ -81,29 +135,43 @@ func Init(params InitParam) REST {
 			http:         httpServer,
 			uc:           params.Uc,
 			scheduler:    params.Scheduler,
+			metrics:      params.Metrics,
+			tracer:       params.Tracer,
+			ratelimiter:  params.RateLimiter,
+			idempotency:  params.Idempotency,
+			accesslog:    params.AccessLog,
+			health:       params.Health,
+			tls:          params.TLS,
+			cursor:       params.Cursor,
+			shutdown:     make(chan struct{}),
 		}
 
+		// Set Response and Request Formats
+		r.codecs = newCodecs(r.json)
+
+		// Set Request Validation
+		r.initValidation()
+
 		// Set CORS
-		switch r.conf.CORS.Mode {
-		case "allowall":
-			r.http.Use(cors.New(cors.Config{
-				AllowAllOrigins: true,
-				AllowHeaders:    []string{"*"},
-				AllowMethods: []string{
-					http.MethodHead,
-					http.MethodGet,
-					http.MethodPost,
-					http.MethodPut,
-					http.MethodPatch,
-					http.MethodDelete,
-				},
-			}))
-		default:
-			r.http.Use(cors.New(cors.DefaultConfig()))
-		}
+		r.http.Use(r.initCORS())
+
+		// Set Tracing
+		r.http.Use(r.StartTrace)
+
+		// Set mTLS Client Identity
+		r.http.Use(r.addClientIdentity)
+
+		// Set Metrics
+		r.http.Use(r.RecordMetrics)
+
+		// Set Compression, outside the access log so it captures the bodies before encoding
+		r.http.Use(r.initCompress())
+
+		// Set Access Log
+		r.http.Use(r.AccessLog)
 
 		// Set Recovery
-		r.http.Use(gin.Recovery())
+		r.http.Use(r.Recover)
 
 		// Set Timeout
 		r.http.Use(r.SetTimeout)
@@ -120,25 +188,34 @@ func (r *rest) Run() {
 	ctx, stop := signal.NotifyContext(c, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	port := ":8080"
-	if r.conf.Port != "" {
-		port = fmt.Sprintf(":%s", r.conf.Port)
-	}
+	srv := r.newServer()
+	srv.RegisterOnShutdown(r.stopStreams)
 
-	srv := &http.Server{
-		Addr:              port,
-		Handler:           r.http,
-		ReadHeaderTimeout: 2 * time.Second,
+	// bind before serving so an address already in use stops the service right away
+	ln, err := r.listen(ctx, srv.Addr)
+	if err != nil {
+		r.log.Fatal(ctx, fmt.Sprintf("Listening on %s error: %s", srv.Addr, err.Error()))
 	}
 
 	// Initializing the server in a goroutine so that
 	// it won't block the graceful shutdown handling below
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			r.log.Error(ctx, fmt.Sprintf("Serving HTTP error: %s", err.Error()))
-		}
-	}()
-	r.log.Info(ctx, fmt.Sprintf("Listening and Serving HTTP on %s", srv.Addr))
+	if r.tls.IsEnabled() {
+		srv.TLSConfig = r.tls.TLSConfig()
+		go func() {
+			// certificates come from the tls config so they can be reloaded without a restart
+			if err := srv.ServeTLS(ln, "", ""); err != nil && err != http.ErrServerClosed {
+				r.log.Error(ctx, fmt.Sprintf("Serving HTTPS error: %s", err.Error()))
+			}
+		}()
+		r.log.Info(ctx, fmt.Sprintf("Listening and Serving HTTPS on %s", srv.Addr))
+	} else {
+		go func() {
+			if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+				r.log.Error(ctx, fmt.Sprintf("Serving HTTP error: %s", err.Error()))
+			}
+		}()
+		r.log.Info(ctx, fmt.Sprintf("Listening and Serving HTTP on %s", srv.Addr))
+	}
 
 	// Listen for the interrupt signal.
 	<-ctx.Done()
@@ -147,31 +224,101 @@ func (r *rest) Run() {
 	stop()
 	r.log.Info(ctx, "Shutting down server...")
 
-	// The context is used to inform the server it has timeout duration to finish
-	// the request it is currently handling
+	r.gracefulStop(c, srv)
+}
+
+// gracefulStop fails readiness, waits the drain period, then gives the server and the
+// scheduler ShutdownTimeout to finish the requests and tasks they are currently handling
+func (r *rest) gracefulStop(c context.Context, srv *http.Server) {
+	// fail readiness first so the load balancer stops sending new requests,
+	// the listener keeps serving whatever still arrives during the drain period
+	r.health.Shutdown()
+	if r.conf.DrainPeriod > 0 {
+		r.log.Info(c, fmt.Sprintf("Draining for %v before closing the listener", r.conf.DrainPeriod))
+		time.Sleep(r.conf.DrainPeriod)
+	}
+
+	// The context is used to inform the server and the scheduler they have timeout duration
+	// to finish the requests and tasks they are currently handling
 	quitctx, cancel := context.WithTimeout(c, r.conf.ShutdownTimeout)
 	defer cancel()
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.scheduler.Stop(quitctx)
+	}()
+
 	if err := srv.Shutdown(quitctx); err != nil {
-		r.log.Fatal(quitctx, fmt.Sprintf("Server Shutdown: %s", err.Error()))
+		r.log.Error(quitctx, fmt.Sprintf("Server Shutdown, in flight requests were cut short: %s", err.Error()))
 	}
+	wg.Wait()
+
+	// no request uses the stores anymore
+	r.ratelimiter.Stop()
+	r.idempotency.Stop()
 	r.log.Info(quitctx, "Server Shut Down.")
 }
 
 func (r *rest) Register() {
 	// server health and testing purpose
 	r.http.GET("/ping", r.Ping)
+	r.http.GET("/healthz", r.Healthz)
+	r.http.GET("/readyz", r.Readyz)
 	r.registerSwaggerRoutes()
 	r.registerPlatformRoutes()
+	r.registerPprofRoutes()
+	r.registerDummyRoutes()
+	r.registerMetricsRoutes()
+	r.registerProblemRoutes()
 
-	commonPrivateMiddlewares := gin.HandlersChain{
-		r.addFieldsToContext, r.BodyLogger,
-	}
+	groups := r.registerRouteGroups()
 
-	// register middlewares
-	v1 := r.http.Group("/v1/", commonPrivateMiddlewares...)
+	// service routes go on the public and private sets, e.g. groups.private.POST("/orders", r.CreateOrder)
 
 	// scheduler
-	v1.POST("/admin/scheduler/trigger", r.TriggerScheduler)
+	groups.admin.POST("/scheduler/trigger",
+		r.Authorize(entity.Authorize{ActionCode: entity.ActionCodeSchedulerTrigger}),
+		r.TriggerScheduler)
+	groups.admin.GET("/scheduler/events",
+		r.Authorize(entity.Authorize{ActionCode: entity.ActionCodeSchedulerView}),
+		r.StreamSchedulerRuns)
+}
+
+// routeGroups are the v1 route sets. They are siblings so a request only takes a token from
+// the bucket of its own set
+type routeGroups struct {
+	public  *gin.RouterGroup
+	private *gin.RouterGroup
+	admin   *gin.RouterGroup
+}
+
+func (r *rest) registerRouteGroups() routeGroups {
+	commonMiddlewares := gin.HandlersChain{
+		r.addFieldsToContext,
+	}
+
+	// public routes and the token verification run before the user is known, a user keyed limit could never apply there
+	for group, rule := range map[string]config.RateLimitRule{
+		rateLimitGroupPublic:         r.conf.RateLimit.Public,
+		rateLimitGroupAuthentication: r.conf.RateLimit.Authentication,
+	} {
+		if r.conf.RateLimit.Enabled && rule.KeyBy == rateLimitKeyUser {
+			r.log.Fatal(context.Background(), fmt.Sprintf("Rate limit of the %s routes can not be keyed by %s", group, rateLimitKeyUser))
+		}
+	}
+
+	// token verification is limited on its own so invalid tokens can not be checked against
+	// the auth provider unbounded
+	v1 := r.http.Group("/v1/", commonMiddlewares...)
+	authenticated := v1.Group("/", r.RateLimit(rateLimitGroupAuthentication, r.conf.RateLimit.Authentication), r.VerifyUser)
+
+	return routeGroups{
+		public:  v1.Group("/", r.RateLimit(rateLimitGroupPublic, r.conf.RateLimit.Public)),
+		private: authenticated.Group("/", r.RateLimit(rateLimitGroupPrivate, r.conf.RateLimit.Private), r.Idempotent),
+		admin:   authenticated.Group("/admin/", r.RateLimit(rateLimitGroupAdmin, r.conf.RateLimit.Admin), r.Idempotent),
+	}
 }
 
 func (r *rest) registerSwaggerRoutes() {
@@ -204,18 +351,72 @@ func (r *rest) registerPlatformRoutes() {
 	}
 }
 
+func (r *rest) registerPprofRoutes() {
+	if r.conf.Profiler.Pprof.Enabled {
+		// block and mutex profiles stay empty unless sampling is turned on
+		runtime.SetBlockProfileRate(r.conf.Profiler.Pprof.BlockProfileRate)
+		runtime.SetMutexProfileFraction(r.conf.Profiler.Pprof.MutexProfileFraction)
+
+		pprofAuth := gin.Accounts{
+			r.conf.Profiler.Pprof.BasicAuth.Username: r.conf.Profiler.Pprof.BasicAuth.Password,
+		}
+
+		prefix := r.conf.Profiler.Pprof.PathPrefix
+		if prefix == "" {
+			prefix = defaultPprofPathPrefix
+		}
+
+		pprofGroup := r.http.Group(prefix, gin.BasicAuthForRealm(pprofAuth, "Restricted"))
+		pprofGroup.GET("/", gin.WrapF(pprof.Index))
+		pprofGroup.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		pprofGroup.GET("/profile", gin.WrapF(pprof.Profile))
+		pprofGroup.GET("/symbol", gin.WrapF(pprof.Symbol))
+		pprofGroup.POST("/symbol", gin.WrapF(pprof.Symbol))
+		pprofGroup.GET("/trace", gin.WrapF(pprof.Trace))
+		pprofGroup.GET("/allocs", gin.WrapH(pprof.Handler("allocs")))
+		pprofGroup.GET("/block", gin.WrapH(pprof.Handler("block")))
+		pprofGroup.GET("/goroutine", gin.WrapH(pprof.Handler("goroutine")))
+		pprofGroup.GET("/heap", gin.WrapH(pprof.Handler("heap")))
+		pprofGroup.GET("/mutex", gin.WrapH(pprof.Handler("mutex")))
+		pprofGroup.GET("/threadcreate", gin.WrapH(pprof.Handler("threadcreate")))
+	}
+}
+
+func (r *rest) registerMetricsRoutes() {
+	if r.metrics.IsEnabled() {
+		metricsAuth := gin.Accounts{
+			r.conf.Metrics.BasicAuth.Username: r.conf.Metrics.BasicAuth.Password,
+		}
+
+		path := r.conf.Metrics.Path
+		if path == "" {
+			path = defaultMetricsPath
+		}
+
+		r.http.GET(path,
+			gin.BasicAuthForRealm(metricsAuth, "Restricted"),
+			gin.WrapH(r.metrics.Handler()))
+	}
+}
+
 func (r *rest) platformConfig(ctx *gin.Context) {
 	conf := r.configreader.AllSettings()
 
-	switch ctx.Query("output") {
-	case "yaml":
-		c, err := yaml.Marshal(conf)
-		if err != nil {
-			r.httpRespError(ctx, err)
+	// ?output=yaml predates content negotiation and still takes precedence over Accept
+	cd, ok := r.codecs.byName(ctx.Query("output"))
+	if !ok {
+		var notAcceptable bool
+		if cd, notAcceptable = r.responseCodec(ctx); notAcceptable {
+			r.httpRespError(ctx, errNotAcceptable(ctx.GetHeader(headerAccept)))
 			return
 		}
-		ctx.String(http.StatusOK, string(c))
-	default:
-		ctx.IndentedJSON(http.StatusOK, conf)
 	}
+
+	raw, err := r.codecs.marshal(cd, conf)
+	if err != nil {
+		r.httpRespError(ctx, errors.NewWithCode(codes.CodeInternalServerError, "%s", err.Error()))
+		return
+	}
+
+	ctx.Data(http.StatusOK, cd.contentType, raw)
 }
@@ -0,0 +1,193 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/auth"
+	"github.com/downsized-devs/sdk-go/parser"
+	mock_auth "github.com/downsized-devs/sdk-go/tests/mock/auth"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"github.com/downsized-devs/template-service-go/src/handler/scheduler"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/health"
+	"github.com/downsized-devs/template-service-go/src/utils/idempotency"
+	"github.com/downsized-devs/template-service-go/src/utils/ratelimiter"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+)
+
+// newTestRest builds a rest with an empty router, anonymous users and a logger accepting anything
+func newTestRest(t *testing.T, conf config.GinConfig) *rest {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	logMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logMock.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	logMock.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	logMock.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	authMock := mock_auth.NewMockInterface(ctrl)
+	authMock.EXPECT().GetUserAuthInfo(gomock.Any()).Return(auth.UserAuthInfo{}, nil).AnyTimes()
+
+	json := parser.InitParser(logMock, parser.Options{}).JsonParser()
+
+	return &rest{
+		http:     gin.New(),
+		conf:     conf,
+		log:      logMock,
+		auth:     authMock,
+		json:     json,
+		codecs:   newCodecs(json),
+		shutdown: make(chan struct{}),
+	}
+}
+
+// stopScheduler is a scheduler whose Stop is observed by the test
+type stopScheduler struct {
+	scheduler.Interface
+	stop func(ctx context.Context)
+}
+
+func (s *stopScheduler) Stop(ctx context.Context) {
+	s.stop(ctx)
+}
+
+// stopRateLimiter is a rate limiter whose Stop is observed by the test
+type stopRateLimiter struct {
+	ratelimiter.Interface
+	stopped bool
+}
+
+func (s *stopRateLimiter) Stop() {
+	s.stopped = true
+}
+
+// stopIdempotency is an idempotency store whose Stop is observed by the test
+type stopIdempotency struct {
+	idempotency.Interface
+	stopped bool
+}
+
+func (s *stopIdempotency) Stop() {
+	s.stopped = true
+}
+
+func Test_rest_gracefulStop(t *testing.T) {
+	tests := []struct {
+		name            string
+		conf            config.GinConfig
+		requestDuration time.Duration
+		wantMinDuration time.Duration
+		wantMaxDuration time.Duration
+	}{
+		{
+			name:            "in flight request finishes after the drain period",
+			conf:            config.GinConfig{DrainPeriod: 50 * time.Millisecond, ShutdownTimeout: time.Second},
+			requestDuration: 100 * time.Millisecond,
+			wantMinDuration: 100 * time.Millisecond,
+			wantMaxDuration: time.Second,
+		},
+		{
+			name:            "in flight request cut short by the shutdown timeout",
+			conf:            config.GinConfig{ShutdownTimeout: 50 * time.Millisecond},
+			requestDuration: 300 * time.Millisecond,
+			wantMinDuration: 50 * time.Millisecond,
+			wantMaxDuration: 200 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, tt.conf)
+			r.health = health.Init(health.Config{}, r.log)
+
+			started := make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				close(started)
+				time.Sleep(tt.requestDuration)
+			}))
+			defer srv.Close()
+			go http.Get(srv.URL)
+			<-started
+
+			// the scheduler is stopped after readiness failed, with the shutdown deadline
+			begin := time.Now()
+			schedulerStopped := false
+			r.scheduler = &stopScheduler{stop: func(ctx context.Context) {
+				schedulerStopped = true
+				if !r.health.IsShuttingDown() {
+					t.Errorf("gracefulStop() stopped the scheduler while still ready")
+				}
+				if time.Since(begin) < tt.conf.DrainPeriod {
+					t.Errorf("gracefulStop() stopped the scheduler before the drain period")
+				}
+				if _, ok := ctx.Deadline(); !ok {
+					t.Errorf("gracefulStop() stopped the scheduler without a deadline")
+				}
+			}}
+
+			rl := &stopRateLimiter{}
+			r.ratelimiter = rl
+			idem := &stopIdempotency{}
+			r.idempotency = idem
+
+			r.gracefulStop(context.Background(), srv.Config)
+
+			if !schedulerStopped {
+				t.Errorf("gracefulStop() did not stop the scheduler")
+			}
+			if !rl.stopped {
+				t.Errorf("gracefulStop() did not stop the rate limiter cleanup")
+			}
+			if !idem.stopped {
+				t.Errorf("gracefulStop() did not stop the idempotency cleanup")
+			}
+			if elapsed := time.Since(begin); elapsed < tt.wantMinDuration || elapsed > tt.wantMaxDuration {
+				t.Errorf("gracefulStop() took %v, want between %v and %v", elapsed, tt.wantMinDuration, tt.wantMaxDuration)
+			}
+		})
+	}
+}
+
+func Test_rest_registerRouteGroups(t *testing.T) {
+	rule := config.RateLimitRule{Limit: 1, Period: time.Minute, KeyBy: rateLimitKeyIP}
+	r := newTestRest(t, config.GinConfig{RateLimit: config.RateLimitConfig{
+		Enabled:        true,
+		Public:         rule,
+		Authentication: rule,
+		Private:        rule,
+		Admin:          rule,
+	}})
+	r.ratelimiter = ratelimiter.Init(ratelimiter.Config{}, r.log, nil)
+	defer r.ratelimiter.Stop()
+
+	groups := r.registerRouteGroups()
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	groups.public.GET("/items", ok)
+	groups.private.GET("/orders", ok)
+	groups.admin.GET("/reports", ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "public route needs no token", path: "/v1/items", wantStatus: http.StatusOK},
+		{name: "public bucket is spent", path: "/v1/items", wantStatus: http.StatusTooManyRequests},
+		{name: "private route needs a token", path: "/v1/orders", wantStatus: http.StatusUnauthorized},
+		{name: "token verification bucket is spent", path: "/v1/admin/reports", wantStatus: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %d, want %d, body %s", tt.path, rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
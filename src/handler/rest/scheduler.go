@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"strconv"
+
 	"github.com/downsized-devs/sdk-go/codes"
 	"github.com/downsized-devs/template-service-go/src/business/entity"
 	"github.com/gin-gonic/gin"
@@ -10,12 +12,16 @@ import (
 // @Description Trigger Scheduler
 // @Security BearerAuth
 // @Tags Scheduler
+// @Param Idempotency-Key header string false "Replays the first response for retries with the same key"
 // @Param trigger_input body entity.TriggerSchedulerParams true "Parameter for triggering scheduler"
 // @Produce json
 // @Success 200 {object} entity.HTTPResp{}
+// @Failure 400 {object} entity.HTTPResp{}
 // @Failure 500 {object} entity.HTTPResp{}
 // @Failure 401 {object} entity.HTTPResp{}
+// @Failure 403 {object} entity.HTTPResp{}
 // @Failure 404 {object} entity.HTTPResp{}
+// @Failure 409 {object} entity.HTTPResp{}
 // @Router /v1/admin/scheduler/trigger [POST]
 func (r *rest) TriggerScheduler(ctx *gin.Context) {
 	triggerParams := entity.TriggerSchedulerParams{}
@@ -31,3 +37,59 @@ func (r *rest) TriggerScheduler(ctx *gin.Context) {
 
 	r.httpRespSuccess(ctx, codes.CodeSuccess, nil, nil)
 }
+
+// @Summary Stream Scheduler Runs
+// @Description Streams scheduler run events as server sent events, named after the run status with the
+// @Description event id as the SSE id. Reconnecting with Last-Event-ID, or the lastEventId query param,
+// @Description first replays the recent events after it
+// @Security BearerAuth
+// @Tags Scheduler
+// @Param Last-Event-ID header string false "Resume after this event id"
+// @Param lastEventId query string false "Resume after this event id, for clients unable to set headers"
+// @Produce text/event-stream
+// @Success 200 {object} entity.SchedulerRunEvent{}
+// @Failure 401 {object} entity.HTTPResp{}
+// @Failure 403 {object} entity.HTTPResp{}
+// @Router /v1/admin/scheduler/events [GET]
+func (r *rest) StreamSchedulerRuns(ctx *gin.Context) {
+	stream := r.openEventStream(ctx)
+	defer stream.Close()
+
+	// an unknown or malformed id starts from the next event
+	afterID, _ := strconv.ParseInt(stream.LastEventID, 10, 64)
+	backlog, runs, unsubscribe := r.scheduler.SubscribeRuns(afterID)
+	defer unsubscribe()
+
+	for _, run := range backlog {
+		if err := stream.Send(schedulerRunEvent(run)); err != nil {
+			return
+		}
+	}
+
+	for {
+		select {
+		case run, ok := <-runs:
+			// closed when this stream fell behind, the client resumes from its last event
+			if !ok {
+				return
+			}
+			if err := stream.Send(schedulerRunEvent(run)); err != nil {
+				return
+			}
+		case <-stream.Heartbeat():
+			if err := stream.Ping(); err != nil {
+				return
+			}
+		case <-stream.Done():
+			return
+		}
+	}
+}
+
+func schedulerRunEvent(run entity.SchedulerRunEvent) sseEvent {
+	return sseEvent{
+		ID:    strconv.FormatInt(run.ID, 10),
+		Event: run.Status,
+		Data:  run,
+	}
+}
@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/netutil"
+)
+
+const (
+	defaultPort              string        = "8080"
+	defaultReadHeaderTimeout time.Duration = 2 * time.Second
+	defaultReadTimeout       time.Duration = 30 * time.Second
+	defaultIdleTimeout       time.Duration = 120 * time.Second
+	defaultTCPKeepAlive      time.Duration = 15 * time.Second
+
+	// the write timeout leaves room after the request timeout so the timeout response still gets out
+	writeTimeoutMargin time.Duration = 10 * time.Second
+)
+
+// newServer builds the http server from the config, falling back to defaults hardened against slow clients
+func (r *rest) newServer() *http.Server {
+	conf := r.conf.Server
+
+	port := defaultPort
+	if r.conf.Port != "" {
+		port = r.conf.Port
+	}
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%s", port),
+		Handler:           r.http,
+		ReadHeaderTimeout: withDefaultDuration(conf.ReadHeaderTimeout, defaultReadHeaderTimeout),
+		ReadTimeout:       withDefaultDuration(conf.ReadTimeout, defaultReadTimeout),
+		WriteTimeout:      conf.WriteTimeout,
+		IdleTimeout:       withDefaultDuration(conf.IdleTimeout, defaultIdleTimeout),
+		MaxHeaderBytes:    conf.MaxHeaderBytes,
+	}
+
+	if srv.WriteTimeout <= 0 {
+		srv.WriteTimeout = r.defaultWriteTimeout()
+	}
+
+	if srv.MaxHeaderBytes <= 0 {
+		srv.MaxHeaderBytes = http.DefaultMaxHeaderBytes
+	}
+
+	srv.SetKeepAlivesEnabled(!conf.DisableKeepAlives)
+
+	return srv
+}
+
+// listen binds the server address, capping the number of concurrently open connections when configured
+func (r *rest) listen(ctx context.Context, addr string) (net.Listener, error) {
+	lc := net.ListenConfig{
+		KeepAlive: withDefaultDuration(r.conf.Server.TCPKeepAlive, defaultTCPKeepAlive),
+	}
+
+	ln, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.conf.Server.MaxConnections > 0 {
+		ln = netutil.LimitListener(ln, r.conf.Server.MaxConnections)
+	}
+
+	return ln, nil
+}
+
+// defaultWriteTimeout outlasts the longest request timeout. Routes running without one, like
+// event streams, lift the write deadline of their own connection and are left out
+func (r *rest) defaultWriteTimeout() time.Duration {
+	longest := r.conf.Timeout
+	if longest <= 0 {
+		return 0
+	}
+
+	for _, d := range r.conf.RouteTimeouts {
+		if d > longest {
+			longest = d
+		}
+	}
+
+	return longest + writeTimeoutMargin
+}
+
+func withDefaultDuration(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+
+	return d
+}
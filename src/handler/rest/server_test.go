@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+)
+
+func Test_rest_defaultWriteTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		conf config.GinConfig
+		want time.Duration
+	}{
+		{
+			name: "global timeout",
+			conf: config.GinConfig{Timeout: 5 * time.Second},
+			want: 5*time.Second + writeTimeoutMargin,
+		},
+		{
+			name: "longest route timeout",
+			conf: config.GinConfig{
+				Timeout:       5 * time.Second,
+				RouteTimeouts: map[string]time.Duration{"/v1/admin/": 2 * time.Minute},
+			},
+			want: 2*time.Minute + writeTimeoutMargin,
+		},
+		{
+			name: "routes without a timeout are left out",
+			conf: config.GinConfig{
+				Timeout: 5 * time.Second,
+				RouteTimeouts: map[string]time.Duration{
+					"/v1/admin/":                 2 * time.Minute,
+					"/v1/admin/scheduler/events": 0,
+				},
+			},
+			want: 2*time.Minute + writeTimeoutMargin,
+		},
+		{
+			name: "no global timeout",
+			conf: config.GinConfig{},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, tt.conf)
+			if got := r.defaultWriteTimeout(); got != tt.want {
+				t.Errorf("defaultWriteTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/appcontext"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/header"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerLastEventID    string        = "Last-Event-ID"
+	headerAccelBuffering string        = "X-Accel-Buffering"
+	queryLastEventID     string        = "lastEventId"
+	mediaEventStream     string        = "text/event-stream"
+	eventStreamCacheCtl  string        = "no-cache, no-transform"
+	defaultSSEHeartbeat  time.Duration = 15 * time.Second
+	defaultSSERetry      time.Duration = 3 * time.Second
+)
+
+// sseEvent is one server sent event, Data is sent as is when it is a string and as json otherwise
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  interface{}
+}
+
+// eventStream writes server sent events. Done closes when the client goes away, the route
+// deadline passes or the server shuts down, so handlers select on it next to their events
+type eventStream struct {
+	ctx       *gin.Context
+	json      func(v interface{}) ([]byte, error)
+	heartbeat *time.Ticker
+	done      context.Context
+	cancel    context.CancelFunc
+
+	// LastEventID is the last event the client received before reconnecting, empty on a first connection
+	LastEventID string
+}
+
+// openEventStream answers with a text/event-stream and sends the retry hint right away. Routes
+// streaming for long should disable their timeout in RouteTimeouts, otherwise the stream ends at the
+// deadline and the client reconnects with the Last-Event-ID it got. Close must be called once done
+func (r *rest) openEventStream(ctx *gin.Context) *eventStream {
+	heartbeat := withDefaultDuration(r.conf.SSE.Heartbeat, defaultSSEHeartbeat)
+	retry := withDefaultDuration(r.conf.SSE.Retry, defaultSSERetry)
+
+	done, cancel := context.WithCancel(ctx.Request.Context())
+	go func() {
+		select {
+		case <-r.shutdown:
+			cancel()
+		case <-done.Done():
+		}
+	}()
+
+	s := &eventStream{
+		ctx:         ctx,
+		json:        r.json.Marshal,
+		heartbeat:   time.NewTicker(heartbeat),
+		done:        done,
+		cancel:      cancel,
+		LastEventID: ctx.GetHeader(headerLastEventID),
+	}
+
+	// clients without custom headers, like a plain EventSource resuming by hand, use the query
+	if s.LastEventID == "" {
+		s.LastEventID = ctx.Query(queryLastEventID)
+	}
+
+	// a stream outlives the server write timeout, clear it where the connection allows it. The
+	// response controller reaches the connection through the Unwrap of every middleware writer
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		r.log.Error(done, err)
+	}
+
+	c := ctx.Request.Context()
+	c = appcontext.SetAppResponseCode(c, codes.CodeSuccess)
+	c = appcontext.SetResponseHttpCode(c, http.StatusOK)
+	ctx.Request = ctx.Request.WithContext(c)
+
+	h := ctx.Writer.Header()
+	h.Set(header.KeyContentType, mediaEventStream)
+	h.Set(header.KeyCacheControl, eventStreamCacheCtl)
+	h.Set(header.KeyRequestID, appcontext.GetRequestId(c))
+	h.Set(headerAccelBuffering, "no")
+	ctx.Status(http.StatusOK)
+
+	s.write(fmt.Sprintf("retry: %d\n\n", retry.Milliseconds()))
+
+	return s
+}
+
+// Send writes the event and flushes it to the client
+func (s *eventStream) Send(event sseEvent) error {
+	data, ok := event.Data.(string)
+	if !ok {
+		raw, err := s.json(event.Data)
+		if err != nil {
+			return err
+		}
+		data = string(raw)
+	}
+
+	b := strings.Builder{}
+	if event.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", sanitizeEventField(event.ID))
+	}
+	if event.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", sanitizeEventField(event.Event))
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+
+	return s.write(b.String())
+}
+
+// Heartbeat ticks when a keep alive comment is due
+func (s *eventStream) Heartbeat() <-chan time.Time {
+	return s.heartbeat.C
+}
+
+// Ping writes a comment line, ignored by clients but keeping the connection busy
+func (s *eventStream) Ping() error {
+	return s.write(": ping\n\n")
+}
+
+func (s *eventStream) Done() <-chan struct{} {
+	return s.done.Done()
+}
+
+func (s *eventStream) Close() {
+	s.heartbeat.Stop()
+	s.cancel()
+}
+
+func (s *eventStream) write(msg string) error {
+	if _, err := s.ctx.Writer.WriteString(msg); err != nil {
+		return err
+	}
+
+	s.ctx.Writer.Flush()
+	return nil
+}
+
+// sanitizeEventField keeps a line break in an id or event name from starting a new field
+func sanitizeEventField(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
+
+// stopStreams ends every open event stream, registered to run when the server shuts down
+// since it only waits for in flight requests and never cancels them
+func (r *rest) stopStreams() {
+	r.shutdownOnce.Do(func() {
+		close(r.shutdown)
+	})
+}
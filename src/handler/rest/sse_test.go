@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/handler/scheduler"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/gin-gonic/gin"
+)
+
+// runsScheduler is a scheduler serving run events from a fixed backlog and a test fed channel
+type runsScheduler struct {
+	scheduler.Interface
+	backlog []entity.SchedulerRunEvent
+	runs    chan entity.SchedulerRunEvent
+}
+
+func (s *runsScheduler) SubscribeRuns(afterID int64) ([]entity.SchedulerRunEvent, <-chan entity.SchedulerRunEvent, func()) {
+	backlog := []entity.SchedulerRunEvent{}
+	for _, run := range s.backlog {
+		if afterID > 0 && run.ID > afterID {
+			backlog = append(backlog, run)
+		}
+	}
+
+	return backlog, s.runs, func() {}
+}
+
+func Test_eventStream_Send(t *testing.T) {
+	tests := []struct {
+		name  string
+		event sseEvent
+		want  string
+	}{
+		{
+			name:  "string data",
+			event: sseEvent{ID: "1", Event: "started", Data: "cleanup"},
+			want:  "id: 1\nevent: started\ndata: cleanup\n\n",
+		},
+		{
+			name:  "multi line data",
+			event: sseEvent{Data: "first\r\nsecond\nthird"},
+			want:  "data: first\ndata: second\ndata: third\n\n",
+		},
+		{
+			name:  "json data",
+			event: sseEvent{Event: "failed", Data: map[string]string{"task": "cleanup"}},
+			want:  "event: failed\ndata: {\"task\":\"cleanup\"}\n\n",
+		},
+		{
+			name:  "line breaks in the id and name",
+			event: sseEvent{ID: "1\nevent: forged", Event: "started\r\n", Data: "x"},
+			want:  "id: 1event: forged\nevent: started\ndata: x\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, config.GinConfig{SSE: config.SSEConfig{Retry: 5 * time.Second}})
+
+			rec := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rec)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/v1/events", nil)
+
+			stream := r.openEventStream(ctx)
+			defer stream.Close()
+
+			if err := stream.Send(tt.event); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			if got := rec.Header().Get("Content-Type"); got != mediaEventStream {
+				t.Errorf("openEventStream() content type = %q, want %q", got, mediaEventStream)
+			}
+			if got := rec.Body.String(); got != "retry: 5000\n\n"+tt.want {
+				t.Errorf("Send() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rest_StreamSchedulerRuns(t *testing.T) {
+	backlog := []entity.SchedulerRunEvent{
+		{ID: 5, Task: "cleanup", Status: entity.SchedulerRunStarted},
+		{ID: 6, Task: "cleanup", Status: entity.SchedulerRunSucceeded},
+		{ID: 7, Task: "report", Status: entity.SchedulerRunStarted},
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		lastEventID string
+		wantIDs     []string
+	}{
+		{
+			name:    "first connection only gets new events",
+			wantIDs: []string{"8"},
+		},
+		{
+			name:        "resume with the last event id header",
+			lastEventID: "5",
+			wantIDs:     []string{"6", "7", "8"},
+		},
+		{
+			name:    "resume with the query param",
+			query:   "?lastEventId=6",
+			wantIDs: []string{"7", "8"},
+		},
+		{
+			name:        "malformed id starts from the next event",
+			lastEventID: "latest",
+			wantIDs:     []string{"8"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, config.GinConfig{})
+			runs := make(chan entity.SchedulerRunEvent, 1)
+			r.scheduler = &runsScheduler{backlog: backlog, runs: runs}
+			r.http.GET("/v1/admin/scheduler/events", r.StreamSchedulerRuns)
+
+			srv := httptest.NewServer(r.http)
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/admin/scheduler/events"+tt.query, nil)
+			if tt.lastEventID != "" {
+				req.Header.Set(headerLastEventID, tt.lastEventID)
+			}
+
+			client := &http.Client{Timeout: 5 * time.Second}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			runs <- entity.SchedulerRunEvent{ID: 8, Task: "report", Status: entity.SchedulerRunSucceeded}
+
+			// read until the live event, then shut down to end the stream
+			got := []string{}
+			lines := bufio.NewScanner(resp.Body)
+			for lines.Scan() {
+				id, ok := strings.CutPrefix(lines.Text(), "id: ")
+				if !ok {
+					continue
+				}
+				got = append(got, id)
+				if id == "8" {
+					break
+				}
+			}
+
+			r.stopStreams()
+			if _, err := io.ReadAll(resp.Body); err != nil {
+				t.Errorf("stream did not end on shutdown: %v", err)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("StreamSchedulerRuns() sent ids %v, want %v", got, tt.wantIDs)
+			}
+		})
+	}
+}
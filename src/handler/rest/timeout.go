@@ -0,0 +1,293 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/appcontext"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/header"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerConnection string = "Connection"
+
+	timeoutCacheControl string = "no-store, no-transform"
+)
+
+// SetTimeout bounds the request by the route timeout. The handler chain runs in its own goroutine
+// so the 504 response goes out as soon as the deadline hits, anything the handler writes after that
+// is dropped. The middleware still waits for the handler to return before giving the gin context back,
+// since gin reuses it for the next request
+func (r *rest) SetTimeout(ctx *gin.Context) {
+	timeout := r.routeTimeout(ctx.FullPath())
+
+	c := appcontext.SetRequestStartTime(ctx.Request.Context(), time.Now())
+	if timeout <= 0 {
+		ctx.Request = ctx.Request.WithContext(c)
+		ctx.Next()
+		return
+	}
+
+	// wrap the request context with a timeout
+	c, cancel := context.WithTimeout(c, timeout)
+
+	// cancel to clear resources after finished
+	defer cancel()
+
+	// replace request with context wrapped request
+	ctx.Request = ctx.Request.WithContext(c)
+	req := ctx.Request
+
+	w := &timeoutWriter{
+		ResponseWriter: ctx.Writer,
+		header:         ctx.Writer.Header().Clone(),
+	}
+	ctx.Writer = w
+	defer func() {
+		ctx.Writer = w.ResponseWriter
+	}()
+
+	done := make(chan struct{})
+	var rec interface{}
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				rec = p
+				if p != http.ErrAbortHandler {
+					rec = &handlerPanic{value: p, stack: debug.Stack()}
+				}
+			}
+			close(done)
+		}()
+
+		ctx.Next()
+	}()
+
+	select {
+	case <-done:
+		w.finish()
+	case <-c.Done():
+		// the client going away is not a timeout, there is nobody left to answer
+		if !errors.Is(c.Err(), context.DeadlineExceeded) {
+			<-done
+			w.finish()
+			break
+		}
+
+		err := withStatus(errors.NewWithCode(codes.CodeContextDeadlineExceeded, "%s", "Context Deadline Exceeded"), http.StatusGatewayTimeout)
+		responded := w.timeout(func(rw gin.ResponseWriter) {
+			r.writeTimeoutResp(rw, req, err)
+		})
+
+		<-done
+
+		if responded {
+			r.log.Error(c, err)
+
+			c = ctx.Request.Context()
+			c = appcontext.SetAppResponseCode(c, codes.CodeContextDeadlineExceeded)
+			c = appcontext.SetAppErrorMessage(c, err.Error())
+			c = appcontext.SetResponseHttpCode(c, http.StatusGatewayTimeout)
+			ctx.Request = ctx.Request.WithContext(c)
+			ctx.Abort()
+		}
+	}
+
+	// handed back to the recover middleware on the request goroutine
+	if rec != nil {
+		panic(rec)
+	}
+}
+
+// routeTimeout returns the longest matching RouteTimeouts entry for the route, keys ending
+// with '/' cover every route of that group. Routes without an entry use the global timeout
+func (r *rest) routeTimeout(route string) time.Duration {
+	timeout, matched := r.conf.Timeout, -1
+	route = strings.ToLower(route)
+
+	for key, d := range r.conf.RouteTimeouts {
+		key = strings.ToLower(key)
+		if len(key) <= matched {
+			continue
+		}
+
+		if route == key || (strings.HasSuffix(key, "/") && strings.HasPrefix(route, key)) {
+			timeout, matched = d, len(key)
+		}
+	}
+
+	return timeout
+}
+
+// writeTimeoutResp answers with a 504 envelope built only from the request. The explicit length
+// and closing the connection let the client finish reading while the handler is still running
+func (r *rest) writeTimeoutResp(w gin.ResponseWriter, req *http.Request, err error) {
+	httpStatus, _, resp := r.newErrorResp(req, err)
+
+	contentType, raw := r.encodeError(req, resp)
+
+	h := w.Header()
+	addVary(h, headerAccept)
+	h.Set(header.KeyContentType, contentType)
+	h.Set(header.KeyCacheControl, timeoutCacheControl)
+	h.Set(header.KeyRequestID, appcontext.GetRequestId(req.Context()))
+	h.Set(headerContentLength, strconv.Itoa(len(raw)))
+	h.Set(headerConnection, "close")
+
+	w.WriteHeader(httpStatus)
+	w.Write(raw)
+	w.Flush()
+}
+
+// handlerPanic keeps the stack of a panic raised on the handler goroutine, the recover
+// middleware only sees the stack of the goroutine that panics again
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+func (p *handlerPanic) String() string {
+	return fmt.Sprintf("%v\n%s", p.value, p.stack)
+}
+
+// timeoutWriter holds the handler headers apart from the real writer, so the timeout response
+// never races with a handler still setting them, and drops every write once timed out
+type timeoutWriter struct {
+	gin.ResponseWriter
+
+	mu          sync.Mutex
+	header      http.Header
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut || w.wroteHeader {
+		return
+	}
+
+	w.status = code
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return
+	}
+
+	w.handOverHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	w.handOverHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return
+	}
+
+	w.handOverHeader()
+	w.ResponseWriter.Flush()
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.wroteHeader && w.status != 0 {
+		return w.status
+	}
+
+	return w.ResponseWriter.Status()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.wroteHeader || w.ResponseWriter.Written()
+}
+
+// finish hands over what the handler set without writing a body, e.g. a bare status
+func (w *timeoutWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.timedOut {
+		w.handOverHeader()
+	}
+}
+
+// timeout stops the handler writes and lets respond answer when nothing has been sent yet
+func (w *timeoutWriter) timeout(respond func(gin.ResponseWriter)) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.timedOut = true
+	if w.wroteHeader {
+		return false
+	}
+
+	respond(w.ResponseWriter)
+	return true
+}
+
+// handOverHeader copies the handler headers and status onto the real writer, mu must be held
+func (w *timeoutWriter) handOverHeader() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	dst := w.ResponseWriter.Header()
+	for k := range dst {
+		delete(dst, k)
+	}
+	for k, v := range w.header {
+		dst[k] = v
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
@@ -0,0 +1,197 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+func Test_rest_routeTimeout(t *testing.T) {
+	conf := config.GinConfig{
+		Timeout: 5 * time.Second,
+		RouteTimeouts: map[string]time.Duration{
+			"/v1/admin/":                   2 * time.Minute,
+			"/v1/admin/scheduler/":         30 * time.Second,
+			"/v1/admin/scheduler/trigger":  time.Minute,
+			"/v1/admin/scheduler/events":   0,
+			"/v1/admin/Dummy/reload":       10 * time.Second,
+			"/v1/admin/scheduler/trigger/": time.Hour,
+		},
+	}
+
+	tests := []struct {
+		name  string
+		route string
+		want  time.Duration
+	}{
+		{
+			name:  "route without an entry uses the global timeout",
+			route: "/v1/tasks",
+			want:  5 * time.Second,
+		},
+		{
+			name:  "group prefix",
+			route: "/v1/admin/platform/config",
+			want:  2 * time.Minute,
+		},
+		{
+			name:  "longest prefix wins",
+			route: "/v1/admin/scheduler/status",
+			want:  30 * time.Second,
+		},
+		{
+			name:  "exact route wins over its group",
+			route: "/v1/admin/scheduler/trigger",
+			want:  time.Minute,
+		},
+		{
+			name:  "zero override",
+			route: "/v1/admin/scheduler/events",
+			want:  0,
+		},
+		{
+			name:  "keys are case insensitive",
+			route: "/v1/admin/dummy/reload",
+			want:  10 * time.Second,
+		},
+		{
+			name:  "key without trailing slash is not a prefix",
+			route: "/v1/admin/scheduler/events/stream",
+			want:  30 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, conf)
+			if got := r.routeTimeout(tt.route); got != tt.want {
+				t.Errorf("routeTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rest_SetTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		routeTimeout  time.Duration
+		handler       func(late chan<- error) gin.HandlerFunc
+		wantStatus    int
+		wantBody      string
+		wantLateError error
+	}{
+		{
+			name:         "finishes in time",
+			routeTimeout: time.Second,
+			handler: func(late chan<- error) gin.HandlerFunc {
+				return func(ctx *gin.Context) {
+					ctx.Header("X-Handler", "set")
+					ctx.String(http.StatusCreated, "done")
+				}
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   "done",
+		},
+		{
+			name:         "times out with the envelope",
+			routeTimeout: 20 * time.Millisecond,
+			handler: func(late chan<- error) gin.HandlerFunc {
+				return func(ctx *gin.Context) {
+					<-ctx.Request.Context().Done()
+				}
+			},
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:         "writes after the timeout are dropped",
+			routeTimeout: 20 * time.Millisecond,
+			handler: func(late chan<- error) gin.HandlerFunc {
+				return func(ctx *gin.Context) {
+					// write once the middleware has answered with the 504
+					<-ctx.Request.Context().Done()
+					time.Sleep(20 * time.Millisecond)
+					ctx.Header("X-Handler", "late")
+					_, err := ctx.Writer.Write([]byte("late"))
+					late <- err
+				}
+			},
+			wantStatus:    http.StatusGatewayTimeout,
+			wantLateError: http.ErrHandlerTimeout,
+		},
+		{
+			name:         "panic in the handler",
+			routeTimeout: time.Second,
+			handler: func(late chan<- error) gin.HandlerFunc {
+				return func(ctx *gin.Context) {
+					panic("boom")
+				}
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:         "route timeout of zero disables the timeout",
+			routeTimeout: 0,
+			handler: func(late chan<- error) gin.HandlerFunc {
+				return func(ctx *gin.Context) {
+					time.Sleep(50 * time.Millisecond)
+					if ctx.Request.Context().Err() != nil {
+						ctx.String(http.StatusGatewayTimeout, "cancelled")
+						return
+					}
+					ctx.String(http.StatusOK, "slow")
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "slow",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, config.GinConfig{
+				Timeout:       10 * time.Millisecond,
+				RouteTimeouts: map[string]time.Duration{"/v1/tasks": tt.routeTimeout},
+			})
+			r.metrics = metrics.Init(metrics.Config{})
+
+			late := make(chan error, 1)
+			r.http.Use(r.Recover, r.SetTimeout)
+			r.http.GET("/v1/tasks", tt.handler(late))
+
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("SetTimeout() status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("SetTimeout() body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+
+			if tt.wantStatus == http.StatusGatewayTimeout {
+				var resp entity.HTTPResp
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("SetTimeout() body is not an envelope: %v, %s", err, rec.Body.String())
+				}
+				if resp.Meta.StatusCode != http.StatusGatewayTimeout || resp.Meta.Error == nil {
+					t.Errorf("SetTimeout() meta = %+v, want a 504 error", resp.Meta)
+				}
+				if rec.Header().Get("X-Handler") != "" || strings.Contains(rec.Body.String(), "late") {
+					t.Errorf("SetTimeout() let a late write through, header %q body %s", rec.Header().Get("X-Handler"), rec.Body.String())
+				}
+			}
+
+			if tt.wantLateError != nil {
+				if err := <-late; err != tt.wantLateError {
+					t.Errorf("late Write() error = %v, want %v", err, tt.wantLateError)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/downsized-devs/sdk-go/appcontext"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/language"
+	"github.com/downsized-devs/template-service-go/src/utils/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+)
+
+// initValidation hooks into the validator gin binds with and adds the custom binding rules,
+// this is the single place the rules of the service are registered
+func (r *rest) initValidation() {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		r.log.Fatal(context.Background(), "Binding validator is not a go-playground validator")
+	}
+
+	r.validation = validation.Init(r.log, engine)
+
+	rules := []validation.Rule{
+		{
+			Tag:  "notblank",
+			Func: validators.NotBlank,
+			Messages: map[string]string{
+				language.English:    "{field} must not be blank",
+				language.Indonesian: "{field} tidak boleh kosong",
+			},
+		},
+	}
+
+	for _, rule := range rules {
+		if err := r.validation.Register(rule); err != nil {
+			r.log.Fatal(context.Background(), fmt.Sprintf("Registering validation rule error: %s", err.Error()))
+		}
+	}
+}
+
+// bindError lists the failed rules per field in the request language, named by the tag the
+// fields were bound with. Any other binding error stays a plain bad request
+func (r *rest) bindError(ctx *gin.Context, obj interface{}, tag string, err error) error {
+	if fieldErr, ok := r.validation.Translate(err, obj, tag, appcontext.GetAcceptLanguage(ctx.Request.Context())); ok {
+		return fieldErr
+	}
+
+	return errors.NewWithCode(codes.CodeBadRequest, "%s", err.Error())
+}
@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/gin-gonic/gin"
+)
+
+// bindParams carries the rules under test without changing what the api entities accept
+type bindParams struct {
+	Name string `json:"name" binding:"required,notblank"`
+}
+
+func Test_rest_Bind_validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		lang        string
+		body        string
+		wantStatus  int
+		wantFields  []entity.FieldError
+	}{
+		{
+			name:        "valid",
+			contentType: "application/json",
+			body:        `{"name":"cleanup"}`,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "missing field",
+			contentType: "application/json",
+			body:        `{}`,
+			wantStatus:  http.StatusBadRequest,
+			wantFields:  []entity.FieldError{{Field: "name", Rule: "required", Message: "name is required"}},
+		},
+		{
+			name:        "custom rule in indonesian",
+			contentType: "application/json",
+			lang:        "id",
+			body:        `{"name":"   "}`,
+			wantStatus:  http.StatusBadRequest,
+			wantFields:  []entity.FieldError{{Field: "name", Rule: "notblank", Message: "name tidak boleh kosong"}},
+		},
+		{
+			name:        "yaml body",
+			contentType: "application/yaml",
+			body:        "name: ' '\n",
+			wantStatus:  http.StatusBadRequest,
+			wantFields:  []entity.FieldError{{Field: "name", Rule: "notblank", Message: "name must not be blank"}},
+		},
+		{
+			name:        "malformed body",
+			contentType: "application/json",
+			body:        `{"name":`,
+			wantStatus:  http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRest(t, config.GinConfig{})
+			r.initValidation()
+			r.http.POST("/v1/items", r.addFieldsToContext, func(ctx *gin.Context) {
+				var param bindParams
+				if err := r.Bind(ctx, &param); err != nil {
+					r.httpRespError(ctx, err)
+					return
+				}
+				r.httpRespSuccess(ctx, codes.CodeSuccess, param, nil)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/items", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Accept-Language", tt.lang)
+			rec := httptest.NewRecorder()
+			r.http.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Bind() status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+
+			var resp entity.HTTPResp
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Meta.Error == nil {
+				t.Fatalf("Bind() envelope = %s, error %v", rec.Body.String(), err)
+			}
+			if !reflect.DeepEqual(resp.Meta.Error.Fields, tt.wantFields) {
+				t.Errorf("Bind() fields = %+v, want %+v", resp.Meta.Error.Fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+)
+
+const (
+	runEventBacklog    int = 100
+	runEventSubscriber int = 16
+)
+
+// runEvents keeps the latest run events so streams can resume, and fans new ones out to the
+// subscribers. IDs start from the boot time in microseconds so they keep growing across restarts
+type runEvents struct {
+	mu      sync.Mutex
+	lastID  int64
+	backlog []entity.SchedulerRunEvent
+	subs    map[chan entity.SchedulerRunEvent]struct{}
+}
+
+func newRunEvents() *runEvents {
+	return &runEvents{
+		lastID: time.Now().UnixMicro(),
+		subs:   map[chan entity.SchedulerRunEvent]struct{}{},
+	}
+}
+
+func (e *runEvents) publish(event entity.SchedulerRunEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastID++
+	event.ID = e.lastID
+
+	e.backlog = append(e.backlog, event)
+	if len(e.backlog) > runEventBacklog {
+		e.backlog = append(e.backlog[:0:0], e.backlog[len(e.backlog)-runEventBacklog:]...)
+	}
+
+	// a subscriber too slow to keep up is dropped rather than holding the task back,
+	// it resumes from the backlog with the last event it received
+	for ch := range e.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(e.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (e *runEvents) subscribe(afterID int64) ([]entity.SchedulerRunEvent, <-chan entity.SchedulerRunEvent, func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	backlog := []entity.SchedulerRunEvent{}
+	if afterID > 0 && afterID < e.lastID {
+		for _, event := range e.backlog {
+			if event.ID > afterID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	ch := make(chan entity.SchedulerRunEvent, runEventSubscriber)
+	e.subs[ch] = struct{}{}
+
+	unsubscribe := func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if _, ok := e.subs[ch]; ok {
+			delete(e.subs, ch)
+			close(ch)
+		}
+	}
+
+	return backlog, ch, unsubscribe
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/downsized-devs/template-service-go/src/business/entity"
+)
+
+func Test_runEvents_subscribe(t *testing.T) {
+	tests := []struct {
+		name        string
+		published   int
+		afterOffset int64
+		wantBacklog int
+	}{
+		{
+			name:        "first subscription has no backlog",
+			published:   3,
+			afterOffset: 0,
+			wantBacklog: 0,
+		},
+		{
+			name:        "resume after a kept event",
+			published:   3,
+			afterOffset: 1,
+			wantBacklog: 2,
+		},
+		{
+			name:        "resume after the latest event",
+			published:   3,
+			afterOffset: 3,
+			wantBacklog: 0,
+		},
+		{
+			name:        "only the latest events are kept",
+			published:   runEventBacklog + 20,
+			afterOffset: 1,
+			wantBacklog: runEventBacklog,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newRunEvents()
+			firstID := e.lastID
+			for i := 0; i < tt.published; i++ {
+				e.publish(entity.SchedulerRunEvent{Task: "cleanup", Status: entity.SchedulerRunStarted})
+			}
+
+			afterID := int64(0)
+			if tt.afterOffset > 0 {
+				afterID = firstID + tt.afterOffset
+			}
+
+			backlog, runs, unsubscribe := e.subscribe(afterID)
+			defer unsubscribe()
+
+			if len(backlog) != tt.wantBacklog {
+				t.Fatalf("subscribe() backlog = %d events, want %d", len(backlog), tt.wantBacklog)
+			}
+			for i := 1; i < len(backlog); i++ {
+				if backlog[i].ID <= backlog[i-1].ID {
+					t.Errorf("subscribe() backlog ids %d and %d do not grow", backlog[i-1].ID, backlog[i].ID)
+				}
+			}
+
+			e.publish(entity.SchedulerRunEvent{Task: "cleanup", Status: entity.SchedulerRunSucceeded})
+			if next := <-runs; next.ID != e.lastID {
+				t.Errorf("subscribe() next event id = %d, want %d", next.ID, e.lastID)
+			}
+		})
+	}
+}
+
+func Test_runEvents_slowSubscriber(t *testing.T) {
+	e := newRunEvents()
+	_, runs, unsubscribe := e.subscribe(0)
+
+	for i := 0; i <= runEventSubscriber; i++ {
+		e.publish(entity.SchedulerRunEvent{Task: "cleanup", Status: entity.SchedulerRunStarted})
+	}
+
+	received := 0
+	for range runs {
+		received++
+	}
+	if received != runEventSubscriber {
+		t.Errorf("slow subscriber received %d events before being dropped, want %d", received, runEventSubscriber)
+	}
+
+	// unsubscribing a dropped subscriber is a no-op
+	unsubscribe()
+}
@@ -3,6 +3,8 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
+	"sort"
 	"time"
 
 	"github.com/downsized-devs/sdk-go/appcontext"
@@ -11,7 +13,10 @@ import (
 	"github.com/downsized-devs/sdk-go/errors"
 	"github.com/downsized-devs/template-service-go/src/business/entity"
 	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"github.com/downsized-devs/template-service-go/src/utils/tracer"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -23,6 +28,12 @@ const (
 	schedulerDoneError     string = "Running scheduler %s error: %v"
 	schedulerDoneSuccess   string = "Running scheduler %s success"
 	schedulerTimeExecution string = "Scheduler %s done in %v"
+	schedulerSpanName      string = "scheduler %s"
+	schedulerPanic         string = "Recovered from panic in scheduler %s: %v\n%s"
+	schedulerStopCutShort  string = "Scheduler stop timed out, cancelling running tasks: %s"
+	schedulerStopAbandoned string = "Scheduler tasks still running after cancellation: %s"
+
+	schedulerCancelGrace time.Duration = time.Second
 
 	schedulerTimeTypeExact    string = "daily"
 	schedulerTimeTypeInterval string = "interval"
@@ -62,21 +73,89 @@ func (s *scheduler) AssignTask(conf config.SchedulerTaskConf, task handlerFunc)
 
 func (s *scheduler) taskWrapper(conf config.SchedulerTaskConf, task handlerFunc) func() {
 	return func() {
-		ctx := s.createContext(conf)
+		ctx, span := s.tracer.Start(s.createContext(conf), fmt.Sprintf(schedulerSpanName, conf.Name),
+			trace.WithSpanKind(trace.SpanKindInternal), trace.WithNewRoot())
+		defer span.End()
+
 		s.log.Info(ctx, fmt.Sprintf(schedulerRunning, conf.Name))
-		if err := task(ctx); err != nil {
+		startTime := appcontext.GetRequestStartTime(ctx)
+		runID := appcontext.GetRequestId(ctx)
+		s.events.publish(entity.SchedulerRunEvent{
+			Task:      conf.Name,
+			RunID:     runID,
+			Status:    entity.SchedulerRunStarted,
+			Timestamp: startTime,
+		})
+
+		done := s.trackRunning(conf.Name)
+		err := s.runTask(ctx, conf, task)
+		done()
+		tracer.SetStatus(span, 0, err)
+
+		finished := entity.SchedulerRunEvent{
+			Task:       conf.Name,
+			RunID:      runID,
+			Status:     entity.SchedulerRunSucceeded,
+			DurationMs: time.Since(startTime).Milliseconds(),
+			Timestamp:  time.Now(),
+		}
+		if err != nil {
 			s.log.Error(ctx, fmt.Sprintf(schedulerDoneError, conf.Name, err))
+			finished.Status, finished.Error = entity.SchedulerRunFailed, err.Error()
 		} else {
 			s.log.Info(ctx, fmt.Sprintf(schedulerDoneSuccess, conf.Name))
 		}
+		s.events.publish(finished)
 
-		startTime := appcontext.GetRequestStartTime(ctx)
+		s.metrics.SchedulerRunObserve(conf.Name, time.Since(startTime), err)
 		s.log.Info(ctx, fmt.Sprintf(schedulerTimeExecution, conf.Name, time.Since(startTime)))
 	}
 }
 
+// runTask runs the task, turning a panic into an error so one bad run can not crash the process
+func (s *scheduler) runTask(ctx context.Context, conf config.SchedulerTaskConf, task handlerFunc) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			s.log.Error(ctx, fmt.Sprintf(schedulerPanic, conf.Name, rec, debug.Stack()))
+			s.metrics.PanicRecovered(metrics.PanicSourceScheduler, conf.Name)
+			err = errors.NewWithCode(codes.CodeInternalServerError, "scheduler %s panicked: %v", conf.Name, rec)
+		}
+	}()
+
+	return task(ctx)
+}
+
+// trackRunning marks a run of the task as in progress and returns the func marking it done
+func (s *scheduler) trackRunning(name string) func() {
+	s.mu.Lock()
+	s.running[name]++
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		s.running[name]--
+		if s.running[name] <= 0 {
+			delete(s.running, name)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *scheduler) runningTasks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.running))
+	for name := range s.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
 func (s *scheduler) createContext(conf config.SchedulerTaskConf) context.Context {
-	ctx := context.Background()
+	ctx := s.ctx
 	ctx = appcontext.SetUserAgent(ctx, fmt.Sprintf(schedulerUserAgent, conf.Name))
 	ctx = appcontext.SetRequestId(ctx, uuid.New().String())
 	ctx = appcontext.SetRequestStartTime(ctx, time.Now())
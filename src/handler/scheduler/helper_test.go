@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"go.uber.org/mock/gomock"
+)
+
+// scrapeMetrics reads the exposition of the scheduler metrics
+func scrapeMetrics(s *scheduler) string {
+	rec := httptest.NewRecorder()
+	s.metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+func Test_scheduler_runTask(t *testing.T) {
+	errTask := errors.New("task failed")
+	conf := config.SchedulerTaskConf{Name: "task"}
+
+	tests := []struct {
+		name        string
+		task        handlerFunc
+		wantErr     error
+		wantErrMsg  string
+		wantCounted bool
+	}{
+		{
+			name: "success",
+			task: func(ctx context.Context) error { return nil },
+		},
+		{
+			name:       "task error is returned as is",
+			task:       func(ctx context.Context) error { return errTask },
+			wantErr:    errTask,
+			wantErrMsg: errTask.Error(),
+		},
+		{
+			name:        "panic becomes an error",
+			task:        func(ctx context.Context) error { panic("boom") },
+			wantErrMsg:  "scheduler task panicked: boom",
+			wantCounted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScheduler(t)
+			s.metrics = metrics.Init(metrics.Config{Enabled: true})
+
+			err := s.runTask(context.Background(), conf, tt.task)
+			if tt.wantErr != nil && err != tt.wantErr {
+				t.Errorf("runTask() error = %v, want %v", err, tt.wantErr)
+			}
+			if (err != nil) != (tt.wantErrMsg != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErrMsg)) {
+				t.Errorf("runTask() error = %v, want %q", err, tt.wantErrMsg)
+			}
+
+			counted := strings.Contains(scrapeMetrics(s), `panics_recovered_total{name="task",source="scheduler"} 1`)
+			if counted != tt.wantCounted {
+				t.Errorf("runTask() counted the panic = %v, want %v", counted, tt.wantCounted)
+			}
+		})
+	}
+}
+
+func Test_scheduler_taskWrapper_panic(t *testing.T) {
+	s := newTestScheduler(t)
+	s.metrics = metrics.Init(metrics.Config{Enabled: true})
+
+	var logged []string
+	logMock := mock_log.NewMockInterface(gomock.NewController(t))
+	logMock.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	logMock.EXPECT().Error(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, obj any) {
+		msg, _ := obj.(string)
+		logged = append(logged, msg)
+	}).AnyTimes()
+	s.log = logMock
+
+	s.taskWrapper(config.SchedulerTaskConf{Name: "task"}, func(ctx context.Context) error { panic("boom") })()
+
+	if len(logged) != 2 ||
+		!strings.HasPrefix(logged[0], "Recovered from panic in scheduler task: boom") ||
+		!strings.HasPrefix(logged[1], "Running scheduler task error") {
+		t.Errorf("taskWrapper() logged %q, want the panic then the failed run", logged)
+	}
+	if running := s.runningTasks(); len(running) != 0 {
+		t.Errorf("taskWrapper() left %v running", running)
+	}
+	if body := scrapeMetrics(s); !strings.Contains(body, `scheduler_runs_total{result="failure",scheduler_name="task"} 1`) {
+		t.Errorf("taskWrapper() did not observe the failed run")
+	}
+}
@@ -3,13 +3,21 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/downsized-devs/sdk-go/auth"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
 	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/downsized-devs/template-service-go/src/business/entity"
 	"github.com/downsized-devs/template-service-go/src/business/usecase"
 	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/idempotency"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"github.com/downsized-devs/template-service-go/src/utils/ratelimiter"
+	"github.com/downsized-devs/template-service-go/src/utils/tracer"
 	"github.com/go-co-op/gocron"
 )
 
@@ -20,28 +28,56 @@ var (
 type Interface interface {
 	Run()
 	TriggerScheduler(name string) error
+	// HealthCheck fails while the cron is not running
+	HealthCheck(ctx context.Context) error
+	// Stop keeps the cron from starting new runs and waits for the running tasks until ctx is done,
+	// then cancels their context
+	Stop(ctx context.Context)
+	// SubscribeRuns returns the kept run events after afterID and a channel of the next ones. The
+	// channel is closed when the subscriber falls behind, unsubscribe must be called once done
+	SubscribeRuns(afterID int64) (backlog []entity.SchedulerRunEvent, runs <-chan entity.SchedulerRunEvent, unsubscribe func())
 }
 
 type scheduler struct {
-	cron *gocron.Scheduler
-	conf config.SchedulerConfig
-	log  logger.Interface
-	auth auth.Interface
-	uc   *usecase.Usecases
+	cron        *gocron.Scheduler
+	conf        config.SchedulerConfig
+	log         logger.Interface
+	auth        auth.Interface
+	uc          *usecase.Usecases
+	metrics     metrics.Interface
+	tracer      tracer.Interface
+	idempotency idempotency.Interface
+	ratelimiter ratelimiter.Interface
+
+	// ctx is the parent of every task run, cancelled when a graceful stop runs out of time
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	running map[string]int
+	events  *runEvents
 }
 
-func Init(conf config.SchedulerConfig, log logger.Interface, auth auth.Interface, uc *usecase.Usecases) Interface {
+func Init(conf config.SchedulerConfig, log logger.Interface, auth auth.Interface, uc *usecase.Usecases, metrics metrics.Interface, tracer tracer.Interface, idempotency idempotency.Interface, ratelimiter ratelimiter.Interface) Interface {
 	s := &scheduler{}
 	once.Do(func() {
 		cron := gocron.NewScheduler(time.UTC)
 		cron.TagsUnique()
 
+		ctx, cancel := context.WithCancel(context.Background())
 		s = &scheduler{
-			cron: cron,
-			conf: conf,
-			log:  log,
-			auth: auth,
-			uc:   uc,
+			cron:        cron,
+			conf:        conf,
+			log:         log,
+			auth:        auth,
+			uc:          uc,
+			metrics:     metrics,
+			tracer:      tracer,
+			idempotency: idempotency,
+			ratelimiter: ratelimiter,
+			ctx:         ctx,
+			cancel:      cancel,
+			running:     map[string]int{},
+			events:      newRunEvents(),
 		}
 
 		s.AssignScheduledTasks()
@@ -52,6 +88,8 @@ func Init(conf config.SchedulerConfig, log logger.Interface, auth auth.Interface
 // AssignScheduledTasks will assign task to a specified schedule
 func (s *scheduler) AssignScheduledTasks() {
 	s.AssignTask(s.conf.HelloWorld, s.HelloWorld)
+	s.AssignTask(s.conf.IdempotencyCleanup, s.IdempotencyCleanup)
+	s.AssignTask(s.conf.RateLimitCleanup, s.RateLimitCleanup)
 }
 
 func (s *scheduler) Run() {
@@ -63,8 +101,70 @@ func (s *scheduler) TriggerScheduler(name string) error {
 	return s.cron.RunByTag(name)
 }
 
+func (s *scheduler) Stop(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.cron.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.log.Info(ctx, "Scheduler stopped")
+		return
+	case <-ctx.Done():
+	}
+
+	s.log.Error(ctx, fmt.Sprintf(schedulerStopCutShort, strings.Join(s.runningTasks(), ", ")))
+	s.cancel()
+
+	// tasks honouring their context return right after the cancel
+	select {
+	case <-done:
+		s.log.Info(ctx, "Scheduler stopped")
+	case <-time.After(schedulerCancelGrace):
+		s.log.Error(ctx, fmt.Sprintf(schedulerStopAbandoned, strings.Join(s.runningTasks(), ", ")))
+	}
+}
+
+func (s *scheduler) SubscribeRuns(afterID int64) ([]entity.SchedulerRunEvent, <-chan entity.SchedulerRunEvent, func()) {
+	return s.events.subscribe(afterID)
+}
+
+func (s *scheduler) HealthCheck(ctx context.Context) error {
+	if !s.cron.IsRunning() {
+		return errors.NewWithCode(codes.CodeServerUnavailable, "scheduler is not running")
+	}
+
+	return nil
+}
+
 func (s *scheduler) HelloWorld(ctx context.Context) error {
 	fmt.Println(ctx, "Hello, 世界!")
 
 	return nil
 }
+
+// IdempotencyCleanup removes stored idempotent responses past their TTL
+func (s *scheduler) IdempotencyCleanup(ctx context.Context) error {
+	removed, err := s.idempotency.Cleanup(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.log.Info(ctx, fmt.Sprintf("Removed %d expired idempotency records", removed))
+
+	return nil
+}
+
+// RateLimitCleanup removes rate limit buckets idle long enough to be full again
+func (s *scheduler) RateLimitCleanup(ctx context.Context) error {
+	removed, err := s.ratelimiter.Cleanup(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.log.Info(ctx, fmt.Sprintf("Removed %d idle rate limit buckets", removed))
+
+	return nil
+}
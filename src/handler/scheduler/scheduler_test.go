@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/auth"
+	mock_auth "github.com/downsized-devs/sdk-go/tests/mock/auth"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"github.com/downsized-devs/template-service-go/src/utils/config"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"github.com/downsized-devs/template-service-go/src/utils/tracer"
+	"github.com/go-co-op/gocron"
+	"go.uber.org/mock/gomock"
+)
+
+// newTestScheduler builds a scheduler without tasks, metrics or tracing, the system user and a logger accepting anything
+func newTestScheduler(t *testing.T) *scheduler {
+	ctrl := gomock.NewController(t)
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	logMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logMock.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	logMock.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	logMock.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	authMock := mock_auth.NewMockInterface(ctrl)
+	authMock.EXPECT().SetUserAuthInfo(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, param auth.UserAuthParam) context.Context { return ctx }).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return &scheduler{
+		cron:    gocron.NewScheduler(time.UTC),
+		log:     logMock,
+		auth:    authMock,
+		metrics: metrics.Init(metrics.Config{}),
+		tracer:  tracer.Init(tracer.Config{}, logMock),
+		ctx:     ctx,
+		cancel:  cancel,
+		running: map[string]int{},
+		events:  newRunEvents(),
+	}
+}
+
+func Test_scheduler_Stop(t *testing.T) {
+	tests := []struct {
+		name            string
+		task            handlerFunc
+		timeout         time.Duration
+		wantCancelled   bool
+		wantAbandoned   bool
+		wantMaxDuration time.Duration
+	}{
+		{
+			name:            "running task finishes in time",
+			task:            func(ctx context.Context) error { time.Sleep(50 * time.Millisecond); return nil },
+			timeout:         time.Second,
+			wantMaxDuration: 500 * time.Millisecond,
+		},
+		{
+			name: "running task is cancelled at the deadline",
+			task: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			timeout:         50 * time.Millisecond,
+			wantCancelled:   true,
+			wantMaxDuration: 500 * time.Millisecond,
+		},
+		{
+			name:            "task ignoring its context is abandoned after the grace period",
+			task:            func(ctx context.Context) error { time.Sleep(schedulerCancelGrace + 200*time.Millisecond); return nil },
+			timeout:         50 * time.Millisecond,
+			wantCancelled:   true,
+			wantAbandoned:   true,
+			wantMaxDuration: schedulerCancelGrace + 500*time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScheduler(t)
+
+			started := make(chan struct{})
+			task := func(ctx context.Context) error {
+				close(started)
+				return tt.task(ctx)
+			}
+
+			conf := config.SchedulerTaskConf{Name: "task", Enabled: true, TimeType: schedulerTimeTypeInterval, Interval: time.Hour}
+			s.AssignTask(conf, task)
+			s.Run()
+			<-started
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			begin := time.Now()
+			s.Stop(ctx)
+
+			if elapsed := time.Since(begin); elapsed > tt.wantMaxDuration {
+				t.Errorf("Stop() took %v, want at most %v", elapsed, tt.wantMaxDuration)
+			}
+			if cancelled := s.ctx.Err() != nil; cancelled != tt.wantCancelled {
+				t.Errorf("Stop() cancelled the tasks = %v, want %v", cancelled, tt.wantCancelled)
+			}
+			if s.cron.IsRunning() != tt.wantAbandoned {
+				t.Errorf("Stop() left the cron running = %v, want %v", s.cron.IsRunning(), tt.wantAbandoned)
+			}
+
+			// let an abandoned run finish before the logger mock goes away
+			for s.cron.IsRunning() {
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package accesslog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/downsized-devs/sdk-go/parser"
+)
+
+const (
+	entryType       string = "access"
+	redactedValue   string = "[REDACTED]"
+	omittedBody     string = "[non-json body omitted]"
+	truncatedBody   string = "[body over %d bytes omitted]"
+	defaultBodySize int    = 4096
+)
+
+// defaultQuietRoutes are polled by probes and scrapers, their routine successes are noise
+var defaultQuietRoutes = []string{"/healthz", "/readyz", "/metrics"}
+
+// Interface writes one entry per http request through the application logger, the entry
+// is the json message and the request context adds the usual fields around it
+type Interface interface {
+	IsEnabled() bool
+	// Log writes the entry at a level derived from its status and latency, dropping sampled out successes
+	Log(ctx context.Context, entry Entry)
+	// MaxBodySize is the most bytes of a request or response body worth capturing
+	MaxBodySize() int
+	// Redact masks the configured fields of a json body, non-json bodies are omitted
+	Redact(body []byte, truncated bool) string
+}
+
+// Config samples SuccessSampleRatio of the 2xx and 3xx entries, every other entry is written.
+// Leaving the ratio out writes every success while 0 writes none. Successes of QuietRoutes are
+// never written unless slow. Requests slower than SlowThreshold are written at warn level
+type Config struct {
+	Enabled            bool
+	SuccessSampleRatio *float64
+	QuietRoutes        []string
+	SlowThreshold      time.Duration
+	RequestBody        bool
+	ResponseBody       bool
+	MaxBodySize        int
+	RedactFields       []string
+}
+
+type Entry struct {
+	Method  string
+	Route   string
+	Path    string
+	Status  int
+	AppCode int
+	Latency time.Duration
+	BytesIn int64
+	// UncompressedBytesOut is the response body size before any content encoding
+	UncompressedBytesOut int64
+	ClientIP             string
+	UserID               string
+	RequestID            string
+	TraceID              string
+	UserAgent            string
+	RequestBody          string
+	ResponseBody         string
+}
+
+// message is the logged form of an Entry
+type message struct {
+	Type                 string  `json:"type"`
+	Method               string  `json:"method"`
+	Route                string  `json:"route"`
+	Path                 string  `json:"path"`
+	Status               int     `json:"status"`
+	AppCode              int     `json:"app_resp_code"`
+	LatencyMS            float64 `json:"latency_ms"`
+	Slow                 bool    `json:"slow"`
+	BytesIn              int64   `json:"bytes_in"`
+	UncompressedBytesOut int64   `json:"uncompressed_bytes_out"`
+	ClientIP             string  `json:"client_ip"`
+	UserID               string  `json:"user_id"`
+	RequestID            string  `json:"request_id"`
+	TraceID              string  `json:"trace_id"`
+	UserAgent            string  `json:"user_agent"`
+	RequestBody          string  `json:"request_body,omitempty"`
+	ResponseBody         string  `json:"response_body,omitempty"`
+}
+
+type accessLog struct {
+	conf   Config
+	log    logger.Interface
+	json   parser.JsonInterface
+	redact map[string]bool
+	quiet  map[string]bool
+}
+
+func Init(cfg Config, log logger.Interface, json parser.JsonInterface) Interface {
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaultBodySize
+	}
+
+	if cfg.QuietRoutes == nil {
+		cfg.QuietRoutes = defaultQuietRoutes
+	}
+
+	a := &accessLog{
+		conf:   cfg,
+		log:    log,
+		json:   json,
+		redact: map[string]bool{},
+		quiet:  map[string]bool{},
+	}
+
+	for _, f := range cfg.RedactFields {
+		a.redact[strings.ToLower(f)] = true
+	}
+
+	for _, route := range cfg.QuietRoutes {
+		a.quiet[route] = true
+	}
+
+	return a
+}
+
+func (a *accessLog) IsEnabled() bool {
+	return a.conf.Enabled
+}
+
+func (a *accessLog) sampleSuccess() bool {
+	ratio := a.conf.SuccessSampleRatio
+	switch {
+	case ratio == nil || *ratio >= 1:
+		return true
+	case *ratio <= 0:
+		return false
+	default:
+		return rand.Float64() < *ratio
+	}
+}
+
+func (a *accessLog) MaxBodySize() int {
+	return a.conf.MaxBodySize
+}
+
+func (a *accessLog) Log(ctx context.Context, entry Entry) {
+	slow := a.conf.SlowThreshold > 0 && entry.Latency >= a.conf.SlowThreshold
+
+	var write func(ctx context.Context, obj any)
+	switch {
+	case entry.Status >= http.StatusInternalServerError:
+		write = a.log.Error
+	case entry.Status >= http.StatusBadRequest, slow:
+		write = a.log.Warn
+	default:
+		if a.quiet[entry.Route] || !a.sampleSuccess() {
+			return
+		}
+		write = a.log.Info
+	}
+
+	raw, err := a.json.Marshal(message{
+		Type:                 entryType,
+		Method:               entry.Method,
+		Route:                entry.Route,
+		Path:                 entry.Path,
+		Status:               entry.Status,
+		AppCode:              entry.AppCode,
+		LatencyMS:            float64(entry.Latency) / float64(time.Millisecond),
+		Slow:                 slow,
+		BytesIn:              entry.BytesIn,
+		UncompressedBytesOut: entry.UncompressedBytesOut,
+		ClientIP:             entry.ClientIP,
+		UserID:               entry.UserID,
+		RequestID:            entry.RequestID,
+		TraceID:              entry.TraceID,
+		UserAgent:            entry.UserAgent,
+		RequestBody:          entry.RequestBody,
+		ResponseBody:         entry.ResponseBody,
+	})
+	if err != nil {
+		a.log.Error(ctx, fmt.Sprintf("%s %s [%d] access log error: %s", entry.Method, entry.Path, entry.Status, err.Error()))
+		return
+	}
+
+	write(ctx, string(raw))
+}
+
+func (a *accessLog) Redact(body []byte, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	// a cut json document can not be parsed, so it can not be redacted either
+	if truncated {
+		return fmt.Sprintf(truncatedBody, a.conf.MaxBodySize)
+	}
+
+	// checks the whole body once, redactValue then walks it without parsing the scalars
+	var v interface{}
+	if err := a.json.Unmarshal(body, &v); err != nil {
+		return omittedBody
+	}
+
+	raw, err := a.redactValue(body)
+	if err != nil {
+		return omittedBody
+	}
+
+	return string(raw)
+}
+
+// redactValue walks objects and arrays as raw messages, so scalars, numbers included, are kept
+// as written instead of being rounded through float64
+func (a *accessLog) redactValue(raw json.RawMessage) (json.RawMessage, error) {
+	var obj map[string]json.RawMessage
+	if err := a.json.Unmarshal(raw, &obj); err == nil && obj != nil {
+		for k, child := range obj {
+			if a.redact[strings.ToLower(k)] {
+				obj[k] = json.RawMessage(`"` + redactedValue + `"`)
+				continue
+			}
+			v, err := a.redactValue(child)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = v
+		}
+		return a.json.Marshal(obj)
+	}
+
+	var list []json.RawMessage
+	if err := a.json.Unmarshal(raw, &list); err == nil && list != nil {
+		for i, child := range list {
+			v, err := a.redactValue(child)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return a.json.Marshal(list)
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,209 @@
+package accesslog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/parser"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_accessLog_Log(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	json := parser.InitParser(logMock, parser.Options{}).JsonParser()
+
+	entry := Entry{
+		Method:               "GET",
+		Route:                "/v1/items/:id",
+		Path:                 "/v1/items/1",
+		Status:               200,
+		AppCode:              10000,
+		Latency:              1500 * time.Microsecond,
+		UncompressedBytesOut: 42,
+		RequestID:            "request-id",
+	}
+
+	ratio := func(v float64) *float64 { return &v }
+
+	type mockFields struct {
+		log *mock_log.MockInterface
+	}
+
+	mocks := mockFields{
+		log: logMock,
+	}
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		mockFunc func(m mockFields, got *string)
+		route    string
+		status   int
+		latency  time.Duration
+		want     string
+	}{
+		{
+			name:   "success at info",
+			cfg:    Config{SlowThreshold: time.Second},
+			status: 200,
+			mockFunc: func(m mockFields, got *string) {
+				m.log.EXPECT().Info(gomock.Any(), gomock.Any()).Do(capture(got))
+			},
+			want: `{"type":"access","method":"GET","route":"/v1/items/:id","path":"/v1/items/1","status":200,"app_resp_code":10000,"latency_ms":1.5,"slow":false,"bytes_in":0,"uncompressed_bytes_out":42,"client_ip":"","user_id":"","request_id":"request-id","trace_id":"","user_agent":""}`,
+		},
+		{
+			name:    "slow success at warn",
+			cfg:     Config{SlowThreshold: time.Second},
+			status:  200,
+			latency: 2 * time.Second,
+			mockFunc: func(m mockFields, got *string) {
+				m.log.EXPECT().Warn(gomock.Any(), gomock.Any()).Do(capture(got))
+			},
+			want: `{"type":"access","method":"GET","route":"/v1/items/:id","path":"/v1/items/1","status":200,"app_resp_code":10000,"latency_ms":2000,"slow":true,"bytes_in":0,"uncompressed_bytes_out":42,"client_ip":"","user_id":"","request_id":"request-id","trace_id":"","user_agent":""}`,
+		},
+		{
+			name:   "client error at warn",
+			cfg:    Config{},
+			status: 404,
+			mockFunc: func(m mockFields, got *string) {
+				m.log.EXPECT().Warn(gomock.Any(), gomock.Any()).Do(capture(got))
+			},
+			want: `{"type":"access","method":"GET","route":"/v1/items/:id","path":"/v1/items/1","status":404,"app_resp_code":10000,"latency_ms":1.5,"slow":false,"bytes_in":0,"uncompressed_bytes_out":42,"client_ip":"","user_id":"","request_id":"request-id","trace_id":"","user_agent":""}`,
+		},
+		{
+			name:   "server error at error, never sampled",
+			cfg:    Config{SuccessSampleRatio: ratio(0.000001)},
+			status: 500,
+			mockFunc: func(m mockFields, got *string) {
+				m.log.EXPECT().Error(gomock.Any(), gomock.Any()).Do(capture(got))
+			},
+			want: `{"type":"access","method":"GET","route":"/v1/items/:id","path":"/v1/items/1","status":500,"app_resp_code":10000,"latency_ms":1.5,"slow":false,"bytes_in":0,"uncompressed_bytes_out":42,"client_ip":"","user_id":"","request_id":"request-id","trace_id":"","user_agent":""}`,
+		},
+		{
+			name:     "success dropped at a zero ratio",
+			cfg:      Config{SuccessSampleRatio: ratio(0)},
+			status:   200,
+			mockFunc: func(m mockFields, got *string) {},
+		},
+		{
+			name:     "probe success skipped",
+			cfg:      Config{SlowThreshold: time.Second},
+			route:    "/readyz",
+			status:   200,
+			mockFunc: func(m mockFields, got *string) {},
+		},
+		{
+			name:   "probe failure written",
+			cfg:    Config{SlowThreshold: time.Second},
+			route:  "/readyz",
+			status: 503,
+			mockFunc: func(m mockFields, got *string) {
+				m.log.EXPECT().Error(gomock.Any(), gomock.Any()).Do(capture(got))
+			},
+			want: `{"type":"access","method":"GET","route":"/readyz","path":"/v1/items/1","status":503,"app_resp_code":10000,"latency_ms":1.5,"slow":false,"bytes_in":0,"uncompressed_bytes_out":42,"client_ip":"","user_id":"","request_id":"request-id","trace_id":"","user_agent":""}`,
+		},
+		{
+			name:    "slow probe written",
+			cfg:     Config{SlowThreshold: time.Second},
+			route:   "/metrics",
+			status:  200,
+			latency: 2 * time.Second,
+			mockFunc: func(m mockFields, got *string) {
+				m.log.EXPECT().Warn(gomock.Any(), gomock.Any()).Do(capture(got))
+			},
+			want: `{"type":"access","method":"GET","route":"/metrics","path":"/v1/items/1","status":200,"app_resp_code":10000,"latency_ms":2000,"slow":true,"bytes_in":0,"uncompressed_bytes_out":42,"client_ip":"","user_id":"","request_id":"request-id","trace_id":"","user_agent":""}`,
+		},
+		{
+			name:     "success sampled out",
+			cfg:      Config{SuccessSampleRatio: ratio(0.000001)},
+			status:   200,
+			mockFunc: func(m mockFields, got *string) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			tt.mockFunc(mocks, &got)
+
+			e := entry
+			e.Status = tt.status
+			if tt.route != "" {
+				e.Route = tt.route
+			}
+			if tt.latency > 0 {
+				e.Latency = tt.latency
+			}
+
+			Init(tt.cfg, logMock, json).Log(context.Background(), e)
+			if got != tt.want {
+				t.Errorf("Log() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func capture(got *string) func(ctx context.Context, obj any) {
+	return func(ctx context.Context, obj any) {
+		*got, _ = obj.(string)
+	}
+}
+
+func Test_accessLog_Redact(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	json := parser.InitParser(logMock, parser.Options{}).JsonParser()
+	a := Init(Config{MaxBodySize: 16, RedactFields: []string{"password", "Token"}}, logMock, json)
+
+	tests := []struct {
+		name      string
+		body      string
+		truncated bool
+		want      string
+	}{
+		{
+			name: "empty",
+			body: "",
+			want: "",
+		},
+		{
+			name: "nested fields, any case",
+			body: `{"user":"a","PASSWORD":"secret","items":[{"token":{"v":1}},{"amount":12345678901234567890}]}`,
+			want: `{"PASSWORD":"[REDACTED]","items":[{"token":"[REDACTED]"},{"amount":12345678901234567890}],"user":"a"}`,
+		},
+		{
+			name: "top level array",
+			body: `[{"password":"secret"}, 1.50, null]`,
+			want: `[{"password":"[REDACTED]"},1.50,null]`,
+		},
+		{
+			name: "invalid nested value",
+			body: `{"user":{"name":a}}`,
+			want: omittedBody,
+		},
+		{
+			name: "non-json",
+			body: "name=a&password=secret",
+			want: omittedBody,
+		},
+		{
+			name:      "truncated",
+			body:      `{"password":"sec`,
+			truncated: true,
+			want:      "[body over 16 bytes omitted]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.Redact([]byte(tt.body), tt.truncated); got != tt.want {
+				t.Errorf("Redact() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
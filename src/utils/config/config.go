@@ -6,27 +6,69 @@ import (
 	"github.com/downsized-devs/sdk-go/logger"
 	"github.com/downsized-devs/sdk-go/parser"
 	"github.com/downsized-devs/sdk-go/sql"
+	"github.com/downsized-devs/template-service-go/src/utils/accesslog"
+	"github.com/downsized-devs/template-service-go/src/utils/cursor"
+	"github.com/downsized-devs/template-service-go/src/utils/health"
+	"github.com/downsized-devs/template-service-go/src/utils/idempotency"
+	"github.com/downsized-devs/template-service-go/src/utils/metrics"
+	"github.com/downsized-devs/template-service-go/src/utils/ratelimiter"
+	"github.com/downsized-devs/template-service-go/src/utils/tlsconfig"
+	"github.com/downsized-devs/template-service-go/src/utils/tracer"
 )
 
 type Application struct {
-	Log       logger.Config
-	Gin       GinConfig
-	SQL       sql.Config
-	Parser    parser.Options
-	Scheduler SchedulerConfig
+	Business    BusinessConfig
+	Log         logger.Config
+	Gin         GinConfig
+	SQL         sql.Config
+	Parser      parser.Options
+	Metrics     metrics.Config
+	RateLimiter ratelimiter.Config
+	Idempotency idempotency.Config
+	Cursor      cursor.Config
+	Health      health.Config
+	Tracer      tracer.Config
+	Scheduler   SchedulerConfig
 }
 
+// GinConfig RouteTimeouts overrides Timeout per route path, a key ending with '/' covers a whole
+// group and the longest key wins. A zero duration lets the route run without a deadline
 type GinConfig struct {
 	Port            string
 	Mode            string
-	LogRequest      bool
-	LogResponse     bool
+	Env             string
 	Timeout         time.Duration
 	ShutdownTimeout time.Duration
+	DrainPeriod     time.Duration
+	RouteTimeouts   map[string]time.Duration
+	TLS             tlsconfig.Config
+	Server          ServerConfig
+	AccessLog       accesslog.Config
 	CORS            CORSConfig
 	Meta            GinMeta
 	Swagger         SwaggerConfig
 	Platform        PlatformConfig
+	Dummy           DummyConfig
+	Profiler        ProfilerConfig
+	Metrics         MetricsConfig
+	RateLimit       RateLimitConfig
+	Idempotency     IdempotencyConfig
+	Compression     CompressionConfig
+	Problem         ProblemConfig
+	SSE             SSEConfig
+}
+
+// ServerConfig tunes the http server, zero values fall back to defaults. WriteTimeout defaults
+// to Timeout plus a margin and MaxConnections of zero leaves the listener unlimited
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	DisableKeepAlives bool
+	TCPKeepAlive      time.Duration
+	MaxConnections    int
 }
 
 type GinMeta struct {
@@ -37,9 +79,21 @@ type GinMeta struct {
 	Version     string
 }
 
+// CORSConfig Mode is either allowall or allowlist, left empty it keeps the gin-contrib default policy
+// over AllowOrigins. AllowOrigins entries are scheme://host[:port],
+// optionally with a leading '*.' host label for every subdomain. When Environments has an entry
+// for Gin.Env it replaces the whole policy
 type CORSConfig struct {
-	Mode string
+	Mode             string
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+	Environments     map[string]CORSConfig
 }
+
 type SwaggerConfig struct {
 	Enabled   bool
 	Path      string
@@ -52,11 +106,98 @@ type PlatformConfig struct {
 	BasicAuth BasicAuthConf
 }
 
+type MetricsConfig struct {
+	Path      string
+	BasicAuth BasicAuthConf
+}
+
+type RateLimitConfig struct {
+	Enabled        bool
+	APIKeyHeader   string
+	Public         RateLimitRule
+	Authentication RateLimitRule
+	Private        RateLimitRule
+	Admin          RateLimitRule
+}
+
+// RateLimitRule allows Limit requests in a burst per key, refilled over Period. KeyBy is one of
+// ip, user or apikey. Authentication guards the token verification of the private and admin
+// routes, so like the public routes it runs before the user is known and can not use user
+type RateLimitRule struct {
+	Limit  int64
+	Period time.Duration
+	KeyBy  string
+}
+
+// IdempotencyConfig sets how long a retry waits for a concurrent request with the
+// same Idempotency-Key before it is rejected with a conflict. Bodies larger than MaxBodySize
+// bytes are rejected since they are read whole to fingerprint the request
+type IdempotencyConfig struct {
+	Enabled     bool
+	WaitTimeout time.Duration
+	MaxBodySize int64
+}
+
+// CompressionConfig lists Encodings (br, zstd, gzip) in order of preference. Responses smaller
+// than MinSize bytes or outside ContentTypes ("text/*" style wildcards allowed) are sent as is
+type CompressionConfig struct {
+	Enabled      bool
+	Encodings    []string
+	MinSize      int
+	ContentTypes []string
+}
+
+// ProblemConfig sets the error response format. Mode "envelope" keeps the usual envelope unless
+// the client asks for application/problem+json, "problem" always sends RFC 7807 documents.
+// Problem types are TypeBaseURI followed by the sdk error code, /problems when unset
+type ProblemConfig struct {
+	Mode        string
+	TypeBaseURI string
+}
+
+// SSEConfig Heartbeat is how often an idle event stream sends a comment to keep proxies from
+// closing it, Retry is the reconnect delay hinted to clients. Zero values fall back to 15s and 3s
+type SSEConfig struct {
+	Heartbeat time.Duration
+	Retry     time.Duration
+}
+
+// DummyConfig serves the fixtures of FixtureDir under Path, reloading them takes BasicAuth
+type DummyConfig struct {
+	Enabled    bool
+	Path       string
+	FixtureDir string
+	BasicAuth  BasicAuthConf
+}
+
+type ProfilerConfig struct {
+	Pprof PprofConfig
+}
+
+type PprofConfig struct {
+	Enabled              bool
+	PathPrefix           string
+	BlockProfileRate     int
+	MutexProfileFraction int
+	BasicAuth            BasicAuthConf
+}
+
 type BasicAuthConf struct {
 	Username string
 	Password string
 }
 
+type BusinessConfig struct {
+	Permission PermissionConfig
+}
+
+type PermissionConfig struct {
+	Source   string
+	Table    string
+	CacheTTL time.Duration
+	Roles    map[string][]string
+}
+
 type SchedulerTaskConf struct {
 	Name          string
 	Enabled       bool
@@ -66,7 +207,9 @@ type SchedulerTaskConf struct {
 }
 
 type SchedulerConfig struct {
-	HelloWorld SchedulerTaskConf
+	HelloWorld         SchedulerTaskConf
+	IdempotencyCleanup SchedulerTaskConf
+	RateLimitCleanup   SchedulerTaskConf
 }
 
 func Init() Application {
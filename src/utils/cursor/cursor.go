@@ -0,0 +1,247 @@
+package cursor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/downsized-devs/sdk-go/parser"
+)
+
+const (
+	defaultLimit    int64 = 10
+	defaultMaxLimit int64 = 100
+
+	secretSize    int    = 32
+	keysHashSize  int    = 8
+	cursorSep     string = "."
+	invalidCursor string = "invalid cursor"
+
+	typeInt    string = "i"
+	typeUint   string = "u"
+	typeFloat  string = "f"
+	typeString string = "s"
+	typeBool   string = "b"
+	typeTime   string = "t"
+)
+
+type Interface interface {
+	// Encode signs the sort key values of the last row of a page into an opaque cursor
+	Encode(keys []Key, values []interface{}) (string, error)
+	// Decode verifies the cursor was issued for the same sort keys and returns its values
+	Decode(keys []Key, cursor string) ([]interface{}, error)
+	// Limit applies the default and the maximum page size
+	Limit(limit int64) int64
+}
+
+// Config Secret signs the cursors, when empty a random one is generated so cursors
+// only stay valid until the service restarts and are not shared between instances
+type Config struct {
+	Secret       string
+	DefaultLimit int64
+	MaxLimit     int64
+}
+
+type cursor struct {
+	conf   Config
+	json   parser.JsonInterface
+	secret []byte
+}
+
+type payload struct {
+	Keys   string   `json:"k"`
+	Types  []string `json:"t"`
+	Values []string `json:"v"`
+}
+
+func Init(cfg Config, log logger.Interface, json parser.JsonInterface) Interface {
+	c := &cursor{conf: cfg, json: json, secret: []byte(cfg.Secret)}
+
+	if c.conf.DefaultLimit <= 0 {
+		c.conf.DefaultLimit = defaultLimit
+	}
+
+	if c.conf.MaxLimit <= 0 {
+		c.conf.MaxLimit = defaultMaxLimit
+	}
+
+	if len(c.secret) == 0 {
+		c.secret = make([]byte, secretSize)
+		if _, err := rand.Read(c.secret); err != nil {
+			log.Fatal(context.Background(), fmt.Sprintf("Generating cursor secret error: %s", err.Error()))
+		}
+		log.Warn(context.Background(), "Cursor secret is not set, cursors will not survive a restart")
+	}
+
+	return c
+}
+
+func (c *cursor) Limit(limit int64) int64 {
+	if limit <= 0 {
+		return c.conf.DefaultLimit
+	}
+
+	if limit > c.conf.MaxLimit {
+		return c.conf.MaxLimit
+	}
+
+	return limit
+}
+
+func (c *cursor) Encode(keys []Key, values []interface{}) (string, error) {
+	if len(keys) == 0 || len(keys) != len(values) {
+		return "", errors.NewWithCode(codes.CodeInvalidValue, "cursor needs one value per sort key, got %d for %d", len(values), len(keys))
+	}
+
+	p := payload{
+		Keys:   keysHash(keys),
+		Types:  make([]string, len(values)),
+		Values: make([]string, len(values)),
+	}
+
+	for i, v := range values {
+		t, s, err := encodeValue(v)
+		if err != nil {
+			return "", errors.NewWithCode(codes.CodeInvalidValue, "sort key %s: %s", keys[i].Column, err.Error())
+		}
+		p.Types[i], p.Values[i] = t, s
+	}
+
+	raw, err := c.json.Marshal(p)
+	if err != nil {
+		return "", errors.NewWithCode(codes.CodeJSONMarshalError, "%s", err.Error())
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw) + cursorSep + base64.RawURLEncoding.EncodeToString(c.sign(raw)), nil
+}
+
+func (c *cursor) Decode(keys []Key, token string) ([]interface{}, error) {
+	encoded, signature, ok := strings.Cut(token, cursorSep)
+	if !ok {
+		return nil, errors.NewWithCode(codes.CodeBadRequest, invalidCursor)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.NewWithCode(codes.CodeBadRequest, invalidCursor)
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(raw)) {
+		return nil, errors.NewWithCode(codes.CodeBadRequest, invalidCursor)
+	}
+
+	p := payload{}
+	if err := c.json.Unmarshal(raw, &p); err != nil {
+		return nil, errors.NewWithCode(codes.CodeBadRequest, invalidCursor)
+	}
+
+	// a cursor is only meaningful for the ordering it was issued with
+	if p.Keys != keysHash(keys) || len(p.Values) != len(keys) || len(p.Types) != len(keys) {
+		return nil, errors.NewWithCode(codes.CodeBadRequest, "cursor does not match the requested sort order")
+	}
+
+	values := make([]interface{}, len(keys))
+	for i := range keys {
+		values[i], err = decodeValue(p.Types[i], p.Values[i])
+		if err != nil {
+			return nil, errors.NewWithCode(codes.CodeBadRequest, invalidCursor)
+		}
+	}
+
+	return values, nil
+}
+
+func (c *cursor) sign(raw []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(raw)
+	return mac.Sum(nil)
+}
+
+func keysHash(keys []Key) string {
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s:%t;", k.Column, k.Desc)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:keysHashSize])
+}
+
+// encodeValue keeps values as typed strings so integers never lose precision through json numbers
+func encodeValue(v interface{}) (string, string, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return "", "", err
+		}
+		v = dv
+	}
+
+	switch val := v.(type) {
+	case int:
+		return typeInt, strconv.FormatInt(int64(val), 10), nil
+	case int8:
+		return typeInt, strconv.FormatInt(int64(val), 10), nil
+	case int16:
+		return typeInt, strconv.FormatInt(int64(val), 10), nil
+	case int32:
+		return typeInt, strconv.FormatInt(int64(val), 10), nil
+	case int64:
+		return typeInt, strconv.FormatInt(val, 10), nil
+	case uint:
+		return typeUint, strconv.FormatUint(uint64(val), 10), nil
+	case uint8:
+		return typeUint, strconv.FormatUint(uint64(val), 10), nil
+	case uint16:
+		return typeUint, strconv.FormatUint(uint64(val), 10), nil
+	case uint32:
+		return typeUint, strconv.FormatUint(uint64(val), 10), nil
+	case uint64:
+		return typeUint, strconv.FormatUint(val, 10), nil
+	case float32:
+		return typeFloat, strconv.FormatFloat(float64(val), 'g', -1, 32), nil
+	case float64:
+		return typeFloat, strconv.FormatFloat(val, 'g', -1, 64), nil
+	case string:
+		return typeString, val, nil
+	case []byte:
+		return typeString, string(val), nil
+	case bool:
+		return typeBool, strconv.FormatBool(val), nil
+	case time.Time:
+		return typeTime, val.Format(time.RFC3339Nano), nil
+	case nil:
+		return "", "", errors.NewWithCode(codes.CodeInvalidValue, "sort key value is null")
+	default:
+		return "", "", errors.NewWithCode(codes.CodeInvalidValue, "unsupported sort key value type %T", v)
+	}
+}
+
+func decodeValue(t, s string) (interface{}, error) {
+	switch t {
+	case typeInt:
+		return strconv.ParseInt(s, 10, 64)
+	case typeUint:
+		return strconv.ParseUint(s, 10, 64)
+	case typeFloat:
+		return strconv.ParseFloat(s, 64)
+	case typeString:
+		return s, nil
+	case typeBool:
+		return strconv.ParseBool(s)
+	case typeTime:
+		return time.Parse(time.RFC3339Nano, s)
+	default:
+		return nil, errors.NewWithCode(codes.CodeInvalidValue, "unknown cursor value type %s", t)
+	}
+}
@@ -0,0 +1,156 @@
+package cursor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/null"
+	"github.com/downsized-devs/sdk-go/parser"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestCursor(t *testing.T, cfg Config) Interface {
+	ctrl := gomock.NewController(t)
+	logMock := mock_log.NewMockInterface(ctrl)
+	logMock.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+
+	return Init(cfg, logMock, parser.InitParser(logMock, parser.Options{}).JsonParser())
+}
+
+func Test_cursor_Limit(t *testing.T) {
+	c := newTestCursor(t, Config{Secret: "secret", DefaultLimit: 20, MaxLimit: 50})
+
+	tests := []struct {
+		name  string
+		limit int64
+		want  int64
+	}{
+		{name: "unset", limit: 0, want: 20},
+		{name: "negative", limit: -1, want: 20},
+		{name: "within bounds", limit: 30, want: 30},
+		{name: "over the maximum", limit: 500, want: 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Limit(tt.limit); got != tt.want {
+				t.Errorf("Limit(%d) = %d, want %d", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cursor_EncodeDecode(t *testing.T) {
+	c := newTestCursor(t, Config{Secret: "secret"})
+	keys := []Key{{Column: "created_at", Desc: true}, {Column: "id"}}
+	createdAt := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+
+	tests := []struct {
+		name    string
+		values  []interface{}
+		want    []interface{}
+		wantErr bool
+	}{
+		{
+			name:   "time and int64",
+			values: []interface{}{createdAt, int64(9007199254740993)},
+			want:   []interface{}{createdAt, int64(9007199254740993)},
+		},
+		{
+			name:   "driver valuer",
+			values: []interface{}{null.TimeFrom(createdAt), uint32(7)},
+			want:   []interface{}{createdAt, uint64(7)},
+		},
+		{
+			name:   "string and bool",
+			values: []interface{}{"a.b", true},
+			want:   []interface{}{"a.b", true},
+		},
+		{
+			name:    "null value",
+			values:  []interface{}{nil, int64(1)},
+			wantErr: true,
+		},
+		{
+			name:    "value count mismatch",
+			values:  []interface{}{createdAt},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported type",
+			values:  []interface{}{struct{}{}, int64(1)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := c.Encode(keys, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Encode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := c.Decode(keys, token)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cursor_Decode(t *testing.T) {
+	c := newTestCursor(t, Config{Secret: "secret"})
+	keys := []Key{{Column: "id"}}
+
+	valid, err := c.Encode(keys, []interface{}{int64(1)})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	encoded, signature, _ := strings.Cut(valid, cursorSep)
+
+	other, err := newTestCursor(t, Config{Secret: "other"}).Encode(keys, []interface{}{int64(1)})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    []Key
+		token   string
+		wantErr bool
+	}{
+		{name: "valid", keys: keys, token: valid},
+		{name: "no signature", keys: keys, token: encoded, wantErr: true},
+		{name: "tampered payload", keys: keys, token: encoded + "x" + cursorSep + signature, wantErr: true},
+		{name: "signed with another secret", keys: keys, token: other, wantErr: true},
+		{name: "other sort order", keys: []Key{{Column: "id", Desc: true}}, token: valid, wantErr: true},
+		{name: "garbage", keys: keys, token: "!!!.!!!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Decode(tt.keys, tt.token); (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_cursor_Init_randomSecret(t *testing.T) {
+	keys := []Key{{Column: "id"}}
+
+	token, err := newTestCursor(t, Config{}).Encode(keys, []interface{}{int64(1)})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if _, err := newTestCursor(t, Config{}).Decode(keys, token); err == nil {
+		t.Errorf("Decode() accepted a cursor of another instance without a configured secret")
+	}
+}
@@ -0,0 +1,86 @@
+package cursor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+)
+
+var columnPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Key is one column of the keyset ordering. Columns must be NOT NULL and the last key
+// must be unique, usually the primary key, so no two rows ever tie
+type Key struct {
+	Column string
+	Desc   bool
+}
+
+// Clause holds the SQL fragments of a keyset page, Where is empty on the first page
+type Clause struct {
+	Where   string
+	OrderBy string
+	Args    []interface{}
+}
+
+// Query renders the condition selecting the rows after the given cursor values and the matching
+// ORDER BY. The condition is expanded into OR terms so keys may mix sort directions, e.g.
+// (a > ?) OR (a = ? AND b < ?). Placeholders are '?', rebind them for the driver if needed
+func Query(keys []Key, after []interface{}) (Clause, error) {
+	clause := Clause{}
+	if len(keys) == 0 {
+		return clause, errors.NewWithCode(codes.CodeInvalidValue, "keyset pagination needs at least one sort key")
+	}
+
+	if after != nil && len(after) != len(keys) {
+		return clause, errors.NewWithCode(codes.CodeInvalidValue, "cursor needs one value per sort key, got %d for %d", len(after), len(keys))
+	}
+
+	order := make([]string, len(keys))
+	for i, k := range keys {
+		if !columnPattern.MatchString(k.Column) {
+			return clause, errors.NewWithCode(codes.CodeInvalidValue, "invalid sort column %q", k.Column)
+		}
+
+		order[i] = fmt.Sprintf("%s %s", k.Column, direction(k))
+	}
+	clause.OrderBy = strings.Join(order, ", ")
+
+	if after == nil {
+		return clause, nil
+	}
+
+	terms := make([]string, len(keys))
+	for i, k := range keys {
+		conds := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conds = append(conds, fmt.Sprintf("%s = ?", keys[j].Column))
+			clause.Args = append(clause.Args, after[j])
+		}
+
+		conds = append(conds, fmt.Sprintf("%s %s ?", k.Column, comparator(k)))
+		clause.Args = append(clause.Args, after[i])
+		terms[i] = fmt.Sprintf("(%s)", strings.Join(conds, " AND "))
+	}
+	clause.Where = fmt.Sprintf("(%s)", strings.Join(terms, " OR "))
+
+	return clause, nil
+}
+
+func direction(k Key) string {
+	if k.Desc {
+		return "DESC"
+	}
+
+	return "ASC"
+}
+
+func comparator(k Key) string {
+	if k.Desc {
+		return "<"
+	}
+
+	return ">"
+}
@@ -0,0 +1,72 @@
+package cursor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	type args struct {
+		keys  []Key
+		after []interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    Clause
+		wantErr bool
+	}{
+		{
+			name: "first page",
+			args: args{keys: []Key{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}},
+			want: Clause{OrderBy: "created_at DESC, id DESC"},
+		},
+		{
+			name: "single key",
+			args: args{keys: []Key{{Column: "id"}}, after: []interface{}{int64(5)}},
+			want: Clause{
+				Where:   "((id > ?))",
+				OrderBy: "id ASC",
+				Args:    []interface{}{int64(5)},
+			},
+		},
+		{
+			name: "mixed directions",
+			args: args{keys: []Key{{Column: "p.name"}, {Column: "p.id", Desc: true}}, after: []interface{}{"bob", int64(5)}},
+			want: Clause{
+				Where:   "((p.name > ?) OR (p.name = ? AND p.id < ?))",
+				OrderBy: "p.name ASC, p.id DESC",
+				Args:    []interface{}{"bob", "bob", int64(5)},
+			},
+		},
+		{
+			name:    "no keys",
+			args:    args{},
+			wantErr: true,
+		},
+		{
+			name:    "value count mismatch",
+			args:    args{keys: []Key{{Column: "name"}, {Column: "id"}}, after: []interface{}{"bob"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid column",
+			args:    args{keys: []Key{{Column: "id; DROP TABLE users"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Query(tt.args.keys, tt.args.after)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package fielderror
+
+import (
+	"strings"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+)
+
+// Field is one rejected request field and the reason it was rejected. Rule and Param are
+// only set for binding validation failures, e.g. Rule min with Param 3
+type Field struct {
+	Field   string
+	Rule    string
+	Param   string
+	Message string
+}
+
+// Error is a bad request caused by one or more request fields. It wraps a coded error
+// so the response code still resolves, the fields are listed in the error envelope
+type Error struct {
+	Fields []Field
+	err    error
+}
+
+func New(fields []Field) error {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Field
+	}
+
+	return &Error{
+		Fields: fields,
+		err:    errors.NewWithCode(codes.CodeBadRequest, "invalid fields: %s", strings.Join(names, ", ")),
+	}
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the coded error carrying the response code
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// As returns the field error in the chain of err, if any
+func As(err error) (*Error, bool) {
+	var fe *Error
+	if errors.As(err, &fe) {
+		return fe, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,312 @@
+package fieldset
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/downsized-devs/template-service-go/src/utils/fielderror"
+)
+
+const (
+	fieldSep string = ","
+	pathSep  string = "."
+)
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Set is a parsed fields selection, e.g. id,name,meta.status. A node without
+// children keeps the whole value, a node with children keeps only those
+type Set struct {
+	children map[string]*Set
+	// paths keeps the full path of each child for error reporting
+	paths map[string]string
+}
+
+// Parse reads a comma separated list of dotted json paths
+func Parse(raw string) (*Set, error) {
+	set := &Set{}
+	invalid := []fielderror.Field{}
+
+	for _, path := range strings.Split(raw, fieldSep) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		if !set.add(path) {
+			invalid = append(invalid, fielderror.Field{Field: path, Message: "invalid field path"})
+		}
+	}
+
+	if len(invalid) > 0 {
+		return nil, fielderror.New(invalid)
+	}
+
+	return set, nil
+}
+
+func (s *Set) add(path string) bool {
+	segments := strings.Split(path, pathSep)
+	for _, seg := range segments {
+		if seg == "" {
+			return false
+		}
+	}
+
+	node := s
+	for i, seg := range segments {
+		if node.children == nil {
+			node.children = map[string]*Set{}
+			node.paths = map[string]string{}
+		}
+
+		child, ok := node.children[seg]
+		if !ok {
+			child = &Set{}
+			node.children[seg] = child
+			node.paths[seg] = strings.Join(segments[:i+1], pathSep)
+		}
+
+		// selecting the whole field wins over selecting some of its fields
+		if i == len(segments)-1 {
+			child.children = nil
+		} else if ok && child.children == nil {
+			return true
+		}
+
+		node = child
+	}
+
+	return true
+}
+
+// Validate checks every path against the json tags of t. Interface values are only
+// known at runtime so anything below them is accepted
+func (s *Set) Validate(t reflect.Type) error {
+	invalid := s.validate(t, []fielderror.Field{})
+	if len(invalid) > 0 {
+		return fielderror.New(invalid)
+	}
+
+	return nil
+}
+
+func (s *Set) validate(t reflect.Type, invalid []fielderror.Field) []fielderror.Field {
+	if len(s.children) == 0 || t == nil {
+		return invalid
+	}
+
+	t = elemType(t)
+	switch {
+	case t.Kind() == reflect.Interface:
+		return invalid
+	case isLeaf(t):
+		for name := range s.children {
+			invalid = append(invalid, fielderror.Field{Field: s.paths[name], Message: "field has no sub fields"})
+		}
+	case t.Kind() == reflect.Struct:
+		fields := jsonFields(t)
+		for name, child := range s.children {
+			f, ok := fields[name]
+			if !ok {
+				invalid = append(invalid, fielderror.Field{Field: s.paths[name], Message: "unknown field"})
+				continue
+			}
+			invalid = child.validate(f.typ, invalid)
+		}
+	case t.Kind() == reflect.Map:
+		for _, child := range s.children {
+			invalid = child.validate(t.Elem(), invalid)
+		}
+	default:
+		for name := range s.children {
+			invalid = append(invalid, fielderror.Field{Field: s.paths[name], Message: "unknown field"})
+		}
+	}
+
+	return invalid
+}
+
+// Project keeps only the selected fields of v, walking into slices, arrays and maps.
+// The result marshals like v would, with the unselected fields left out
+func (s *Set) Project(v interface{}) (interface{}, error) {
+	if s == nil || len(s.children) == 0 {
+		return v, nil
+	}
+
+	if err := s.Validate(reflect.TypeOf(v)); err != nil {
+		return nil, err
+	}
+
+	return s.project(reflect.ValueOf(v)), nil
+}
+
+func (s *Set) project(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if len(s.children) == 0 || isLeaf(v.Type()) {
+		return whole(v)
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+		if isLeaf(v.Type()) {
+			return whole(v)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		out := map[string]interface{}{}
+		for name, f := range jsonFields(v.Type()) {
+			child, ok := s.children[name]
+			if !ok {
+				continue
+			}
+
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || (f.omitEmpty && fv.IsZero()) {
+				continue
+			}
+			out[name] = child.project(fv)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = s.project(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return whole(v)
+		}
+
+		out := map[string]interface{}{}
+		for name, child := range s.children {
+			mv := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if mv.IsValid() {
+				out[name] = child.project(mv)
+			}
+		}
+		return out
+	default:
+		return whole(v)
+	}
+}
+
+// whole keeps a value as it is, behind a pointer so marshallers with pointer
+// receivers, like the null types, still apply once it is boxed in an interface
+func whole(v reflect.Value) interface{} {
+	if v.CanAddr() {
+		return v.Addr().Interface()
+	}
+
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p.Interface()
+}
+
+type jsonField struct {
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+// jsonFields lists the fields of a struct by json name, promoting the fields of
+// untagged embedded structs the way encoding/json does
+func jsonFields(t reflect.Type) map[string]jsonField {
+	fields := map[string]jsonField{}
+	collectFields(t, nil, fields)
+	return fields
+}
+
+func collectFields(t reflect.Type, index []int, fields map[string]jsonField) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		idx := append(append([]int{}, index...), i)
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectFields(ft, idx, fields)
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		// fields closer to the top win over promoted ones
+		if _, ok := fields[name]; ok && len(index) > 0 {
+			continue
+		}
+
+		fields[name] = jsonField{index: idx, typ: sf.Type, omitEmpty: strings.Contains(opts, "omitempty")}
+	}
+}
+
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+func elemType(t reflect.Type) reflect.Type {
+	for !isLeaf(t) && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// isLeaf reports types marshalled as a whole, either scalars or types with their own marshaller
+func isLeaf(t reflect.Type) bool {
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer, reflect.Interface:
+		return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+	default:
+		return true
+	}
+}
@@ -0,0 +1,233 @@
+package fieldset
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/template-service-go/src/utils/fielderror"
+)
+
+type testMeta struct {
+	Status string `json:"status"`
+	Score  int    `json:"score"`
+}
+
+type testBase struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testItem struct {
+	testBase
+	Name    string                 `json:"name"`
+	Note    string                 `json:"note,omitempty"`
+	Secret  string                 `json:"-"`
+	Meta    *testMeta              `json:"meta"`
+	Tags    []testMeta             `json:"tags"`
+	Labels  map[string]testMeta    `json:"labels"`
+	Extra   interface{}            `json:"extra"`
+	Raw     map[string]interface{} `json:"raw"`
+	private string
+}
+
+func invalidFields(t *testing.T, err error) []string {
+	t.Helper()
+
+	fe, ok := fielderror.As(err)
+	if !ok {
+		t.Fatalf("error = %v, want a field error", err)
+	}
+
+	names := make([]string, len(fe.Fields))
+	for i, f := range fe.Fields {
+		names[i] = f.Field
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// leaves lists the selected paths, a path ending where the whole value is kept
+func leaves(s *Set, prefix string) []string {
+	out := []string{}
+	for name, child := range s.children {
+		if len(child.children) == 0 {
+			out = append(out, prefix+name)
+			continue
+		}
+		out = append(out, leaves(child, prefix+name+pathSep)...)
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		want        []string
+		wantInvalid []string
+	}{
+		{
+			name: "empty",
+			raw:  " , ",
+			want: []string{},
+		},
+		{
+			name: "nested paths",
+			raw:  "id, meta.status,meta.score",
+			want: []string{"id", "meta.score", "meta.status"},
+		},
+		{
+			name: "whole field wins over its sub fields",
+			raw:  "meta.status,meta,meta.score",
+			want: []string{"meta"},
+		},
+		{
+			name:        "empty segments",
+			raw:         "id,meta.,.name,a..b",
+			wantInvalid: []string{".name", "a..b", "meta."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.raw)
+			if len(tt.wantInvalid) > 0 {
+				if names := invalidFields(t, err); !reflect.DeepEqual(names, tt.wantInvalid) {
+					t.Errorf("Parse() invalid fields = %v, want %v", names, tt.wantInvalid)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if paths := leaves(got, ""); !reflect.DeepEqual(paths, tt.want) {
+				t.Errorf("Parse() = %v, want %v", paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		wantInvalid []string
+	}{
+		{
+			name: "known fields",
+			raw:  "id,created_at,name,meta.status,tags.score,labels.status,extra.anything,raw.key.deep",
+		},
+		{
+			name:        "unknown, ignored and unexported fields",
+			raw:         "nope,Secret,private,meta.nope",
+			wantInvalid: []string{"Secret", "meta.nope", "nope", "private"},
+		},
+		{
+			name:        "sub fields of a scalar or a marshaller",
+			raw:         "name.first,created_at.year",
+			wantInvalid: []string{"created_at.year", "name.first"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			err = set.Validate(reflect.TypeOf([]*testItem{}))
+			if len(tt.wantInvalid) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			if names := invalidFields(t, err); !reflect.DeepEqual(names, tt.wantInvalid) {
+				t.Errorf("Validate() invalid fields = %v, want %v", names, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestSet_Project(t *testing.T) {
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	item := testItem{
+		testBase: testBase{ID: 1, CreatedAt: created},
+		Name:     "first",
+		Secret:   "hidden",
+		Meta:     &testMeta{Status: "active", Score: 10},
+		Tags:     []testMeta{{Status: "a", Score: 1}, {Status: "b", Score: 2}},
+		Labels:   map[string]testMeta{"x": {Status: "x", Score: 3}, "y": {Status: "y", Score: 4}},
+		Extra:    map[string]interface{}{"k": "v"},
+	}
+
+	tests := []struct {
+		name    string
+		raw     string
+		value   interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "no selection keeps everything",
+			raw:   "",
+			value: testMeta{Status: "a", Score: 1},
+			want:  `{"status":"a","score":1}`,
+		},
+		{
+			name:  "promoted and nested fields",
+			raw:   "id,created_at,meta.status,tags.score,labels.x.score",
+			value: item,
+			want:  `{"created_at":"2024-05-06T07:08:09Z","id":1,"labels":{"x":{"score":3}},"meta":{"status":"active"},"tags":[{"score":1},{"score":2}]}`,
+		},
+		{
+			name:  "omitempty and nil values",
+			raw:   "note,meta.status,tags,extra",
+			value: &testItem{},
+			want:  `{"extra":null,"meta":null,"tags":null}`,
+		},
+		{
+			name:  "slices of pointers",
+			raw:   "name",
+			value: []*testItem{&item, nil},
+			want:  `[{"name":"first"},null]`,
+		},
+		{
+			name:    "unknown field",
+			raw:     "nope",
+			value:   item,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			got, err := set.Project(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Project() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			raw, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(raw) != tt.want {
+				t.Errorf("Project() = %s, want %s", raw, tt.want)
+			}
+		})
+	}
+}
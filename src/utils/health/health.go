@@ -0,0 +1,157 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+)
+
+const (
+	StatusUp   string = "up"
+	StatusDown string = "down"
+
+	defaultTimeout time.Duration = 2 * time.Second
+)
+
+// CheckFunc reports a dependency as down by returning an error
+type CheckFunc func(ctx context.Context) error
+
+type Interface interface {
+	// Register adds a named readiness check, registering the same name again replaces it
+	Register(name string, check CheckFunc)
+	// Check runs every registered check concurrently, each bounded by the configured timeout
+	Check(ctx context.Context) Report
+	// Shutdown marks the service as going away, every later Check reports down
+	Shutdown()
+	IsShuttingDown() bool
+}
+
+type Config struct {
+	Timeout time.Duration
+}
+
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// Component is public, readiness is probed without auth, so why a check failed is only logged
+type Component struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+}
+
+type health struct {
+	conf         Config
+	log          logger.Interface
+	mu           sync.RWMutex
+	checks       map[string]CheckFunc
+	shuttingDown atomic.Bool
+}
+
+func Init(cfg Config, log logger.Interface) Interface {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	return &health{
+		conf:   cfg,
+		log:    log,
+		checks: map[string]CheckFunc{},
+	}
+}
+
+func (h *health) Register(name string, check CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks[name] = check
+}
+
+func (h *health) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *health) IsShuttingDown() bool {
+	return h.shuttingDown.Load()
+}
+
+func (h *health) Check(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := make(map[string]CheckFunc, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	report := Report{
+		Status:     StatusUp,
+		Components: make(map[string]Component, len(checks)),
+	}
+
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+			component := h.run(ctx, name, check)
+
+			mu.Lock()
+			report.Components[name] = component
+			if component.Status != StatusUp {
+				report.Status = StatusDown
+			}
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	if h.IsShuttingDown() {
+		report.Status = StatusDown
+	}
+
+	return report
+}
+
+func (h *health) run(ctx context.Context, name string, check CheckFunc) Component {
+	c, cancel := context.WithTimeout(ctx, h.conf.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- errors.NewWithCode(codes.CodeInternalServerError, "check panicked: %v", rec)
+			}
+		}()
+		done <- check(c)
+	}()
+
+	// a check ignoring its context must not hold the whole probe
+	var err error
+	select {
+	case err = <-done:
+	case <-c.Done():
+		err = errors.NewWithCode(codes.CodeContextDeadlineExceeded, "check timed out after %v", h.conf.Timeout)
+	}
+
+	component := Component{
+		Status:  StatusUp,
+		Latency: fmt.Sprintf("%dms", int64(time.Since(start)/time.Millisecond)),
+	}
+
+	if err != nil {
+		h.log.Error(ctx, fmt.Sprintf("Readiness check %s failed after %s: %s", name, component.Latency, err.Error()))
+		component.Status = StatusDown
+	}
+
+	return component
+}
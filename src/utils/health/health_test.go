@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_health_Check(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return fmt.Errorf("connection refused") }
+	stuck := func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+	panicking := func(ctx context.Context) error { panic("nil pointer") }
+
+	tests := []struct {
+		name           string
+		checks         map[string]CheckFunc
+		shutdown       bool
+		wantStatus     string
+		wantComponents map[string]string
+		wantLogged     int
+	}{
+		{
+			name:       "no checks",
+			wantStatus: StatusUp,
+		},
+		{
+			name:           "every check up",
+			checks:         map[string]CheckFunc{"sql_leader": up, "scheduler": up},
+			wantStatus:     StatusUp,
+			wantComponents: map[string]string{"sql_leader": StatusUp, "scheduler": StatusUp},
+		},
+		{
+			name:           "one check down",
+			checks:         map[string]CheckFunc{"sql_leader": up, "sql_follower": down},
+			wantStatus:     StatusDown,
+			wantComponents: map[string]string{"sql_leader": StatusUp, "sql_follower": StatusDown},
+			wantLogged:     1,
+		},
+		{
+			name:           "check ignoring its timeout",
+			checks:         map[string]CheckFunc{"stuck": stuck},
+			wantStatus:     StatusDown,
+			wantComponents: map[string]string{"stuck": StatusDown},
+			wantLogged:     1,
+		},
+		{
+			name:           "panicking check",
+			checks:         map[string]CheckFunc{"panicking": panicking},
+			wantStatus:     StatusDown,
+			wantComponents: map[string]string{"panicking": StatusDown},
+			wantLogged:     1,
+		},
+		{
+			name:           "shutting down",
+			checks:         map[string]CheckFunc{"sql_leader": up},
+			shutdown:       true,
+			wantStatus:     StatusDown,
+			wantComponents: map[string]string{"sql_leader": StatusUp},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// why a check failed is only logged
+			logMock := mock_log.NewMockInterface(ctrl)
+			logMock.EXPECT().Error(gomock.Any(), gomock.Any()).Times(tt.wantLogged)
+
+			h := Init(Config{Timeout: 50 * time.Millisecond}, logMock)
+			for name, check := range tt.checks {
+				h.Register(name, check)
+			}
+			if tt.shutdown {
+				h.Shutdown()
+			}
+
+			got := h.Check(context.Background())
+			if got.Status != tt.wantStatus {
+				t.Errorf("Check() status = %s, want %s", got.Status, tt.wantStatus)
+			}
+			if len(got.Components) != len(tt.wantComponents) {
+				t.Fatalf("Check() components = %+v, want %v", got.Components, tt.wantComponents)
+			}
+			for name, want := range tt.wantComponents {
+				component := got.Components[name]
+				if component.Status != want {
+					t.Errorf("Check() %s status = %s, want %s", name, component.Status, want)
+				}
+				if component.Latency == "" {
+					t.Errorf("Check() %s has no latency", name)
+				}
+			}
+		})
+	}
+}
+
+func Test_health_Register(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := Init(Config{}, mock_log.NewMockInterface(ctrl))
+	h.Register("sql_leader", func(ctx context.Context) error { return fmt.Errorf("connection refused") })
+	h.Register("sql_leader", func(ctx context.Context) error { return nil })
+
+	got := h.Check(context.Background())
+	if got.Status != StatusUp || len(got.Components) != 1 {
+		t.Errorf("Check() = %+v, want the replaced check only", got)
+	}
+}
@@ -0,0 +1,100 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/downsized-devs/sdk-go/parser"
+	"github.com/downsized-devs/sdk-go/sql"
+)
+
+const (
+	StoreMemory string = "memory"
+	StoreSQL    string = "sql"
+
+	defaultTTL             time.Duration = 24 * time.Hour
+	defaultLockTimeout     time.Duration = time.Minute
+	defaultCleanupInterval time.Duration = time.Minute
+	defaultTable           string        = "idempotency_record"
+)
+
+// Interface stores the first response sent for an idempotency key so retries can be replayed
+// instead of running the handler again
+type Interface interface {
+	// Begin claims key for a new request, honouring the claim for lock but never less than the
+	// configured LockTimeout. When the key is already taken the stored record is returned and claimed is false
+	Begin(ctx context.Context, key, fingerprint string, lock time.Duration) (rec Record, claimed bool, err error)
+	// Complete stores the response of a claimed key, keeping it for the configured TTL. token is
+	// the one Begin returned with the claim, a claim taken over since is left alone
+	Complete(ctx context.Context, key, token string, resp Response) error
+	// Release drops a claimed key so the request can be retried, e.g. after a server error
+	Release(ctx context.Context, key, token string) error
+	// Cleanup removes every expired record and returns how many were removed
+	Cleanup(ctx context.Context) (int64, error)
+	// Stop ends the background cleanup of the memory store, it is a no-op for the sql store
+	Stop()
+}
+
+// Config sets how long completed responses are kept (TTL) and how long an in progress claim is
+// honoured at least (LockTimeout) before another request may take the key over. Requests allowed
+// to run longer ask Begin for a longer lock. CleanupInterval is how often the memory store drops
+// expired records, the sql records are removed by the IdempotencyCleanup scheduler task
+type Config struct {
+	Store           string
+	Table           string
+	TTL             time.Duration
+	LockTimeout     time.Duration
+	CleanupInterval time.Duration
+}
+
+// Record is the state of a key. Token identifies the claim and is only set for the caller
+// that made it, Complete and Release take it back to prove the claim is still theirs
+type Record struct {
+	Token       string
+	Fingerprint string
+	Completed   bool
+	Response    Response
+}
+
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+func errClaimLost(key string) error {
+	return errors.NewWithCode(codes.CodeConflict, "idempotency claim on %s is no longer held", key)
+}
+
+func Init(cfg Config, log logger.Interface, json parser.JsonInterface, db sql.Interface) Interface {
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = defaultLockTimeout
+	}
+
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = defaultCleanupInterval
+	}
+
+	if cfg.Table == "" {
+		cfg.Table = defaultTable
+	}
+
+	switch cfg.Store {
+	case StoreSQL:
+		return initSQL(cfg, json, db)
+	case StoreMemory, "":
+		return initMemory(cfg)
+	default:
+		log.Fatal(context.Background(), fmt.Sprintf("Unknown idempotency store %s", cfg.Store))
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+package idempotency
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/parser"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	mock_sql "github.com/downsized-devs/sdk-go/tests/mock/sql"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	dbMock := mock_sql.NewMockInterface(ctrl)
+	json := parser.InitParser(logMock, parser.Options{}).JsonParser()
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		mockFunc func()
+		want     string
+	}{
+		{
+			name:     "memory by default",
+			cfg:      Config{},
+			mockFunc: func() {},
+			want:     "*idempotency.memoryStore",
+		},
+		{
+			name:     "sql",
+			cfg:      Config{Store: StoreSQL},
+			mockFunc: func() {},
+			want:     "*idempotency.sqlStore",
+		},
+		{
+			name: "unknown store",
+			cfg:  Config{Store: "redis"},
+			mockFunc: func() {
+				logMock.EXPECT().Fatal(gomock.Any(), gomock.Any())
+			},
+			want: "<nil>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFunc()
+
+			if got := fmt.Sprintf("%T", Init(tt.cfg, logMock, json, dbMock)); got != tt.want {
+				t.Errorf("Init() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
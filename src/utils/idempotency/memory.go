@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryRecord struct {
+	Record
+	token     string
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	mu          sync.Mutex
+	records     map[string]memoryRecord
+	ttl         time.Duration
+	lockTimeout time.Duration
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+func initMemory(cfg Config) Interface {
+	m := &memoryStore{
+		records:     map[string]memoryRecord{},
+		ttl:         cfg.TTL,
+		lockTimeout: cfg.LockTimeout,
+		stop:        make(chan struct{}),
+	}
+
+	go m.cleanup(cfg.CleanupInterval)
+
+	return m
+}
+
+func (m *memoryStore) Begin(ctx context.Context, key, fingerprint string, lock time.Duration) (Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if rec, ok := m.records[key]; ok && now.Before(rec.expiresAt) {
+		return rec.Record, false, nil
+	}
+
+	token := uuid.NewString()
+	m.records[key] = memoryRecord{
+		Record:    Record{Fingerprint: fingerprint},
+		token:     token,
+		expiresAt: now.Add(max(m.lockTimeout, lock)),
+	}
+
+	return Record{Token: token, Fingerprint: fingerprint}, true, nil
+}
+
+func (m *memoryStore) Complete(ctx context.Context, key, token string, resp Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.records[key]
+	if !ok || rec.token != token {
+		return errClaimLost(key)
+	}
+
+	rec.Completed = true
+	rec.Response = resp
+	rec.expiresAt = time.Now().Add(m.ttl)
+	m.records[key] = rec
+
+	return nil
+}
+
+func (m *memoryStore) Release(ctx context.Context, key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rec, ok := m.records[key]; !ok || rec.token != token {
+		return errClaimLost(key)
+	}
+
+	delete(m.records, key)
+
+	return nil
+}
+
+func (m *memoryStore) Cleanup(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int64
+	now := time.Now()
+	for key, rec := range m.records {
+		if !now.Before(rec.expiresAt) {
+			delete(m.records, key)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// cleanup runs Cleanup every interval until Stop, records in memory go away with the replica so
+// they are swept here rather than by the scheduler
+func (m *memoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.Cleanup(context.Background())
+		}
+	}
+}
+
+func (m *memoryStore) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
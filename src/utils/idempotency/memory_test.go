@@ -0,0 +1,238 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_memoryStore(t *testing.T) {
+	ctx := context.Background()
+	resp := Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       []byte(`{"id":1}`),
+	}
+
+	tests := []struct {
+		name        string
+		run         func(m *memoryStore)
+		fingerprint string
+		wantClaimed bool
+		wantRecord  Record
+	}{
+		{
+			name:        "first request claims the key",
+			run:         func(m *memoryStore) {},
+			fingerprint: "a",
+			wantClaimed: true,
+			wantRecord:  Record{Fingerprint: "a"},
+		},
+		{
+			name: "in progress",
+			run: func(m *memoryStore) {
+				m.Begin(ctx, "key", "a", 0)
+			},
+			fingerprint: "a",
+			wantRecord:  Record{Fingerprint: "a"},
+		},
+		{
+			name: "completed",
+			run: func(m *memoryStore) {
+				rec, _, _ := m.Begin(ctx, "key", "a", 0)
+				m.Complete(ctx, "key", rec.Token, resp)
+			},
+			fingerprint: "a",
+			wantRecord:  Record{Fingerprint: "a", Completed: true, Response: resp},
+		},
+		{
+			name: "other body keeps the first fingerprint",
+			run: func(m *memoryStore) {
+				rec, _, _ := m.Begin(ctx, "key", "a", 0)
+				m.Complete(ctx, "key", rec.Token, resp)
+			},
+			fingerprint: "b",
+			wantRecord:  Record{Fingerprint: "a", Completed: true, Response: resp},
+		},
+		{
+			name: "released",
+			run: func(m *memoryStore) {
+				rec, _, _ := m.Begin(ctx, "key", "a", 0)
+				m.Release(ctx, "key", rec.Token)
+			},
+			fingerprint: "a",
+			wantClaimed: true,
+			wantRecord:  Record{Fingerprint: "a"},
+		},
+		{
+			name: "release with another token keeps the claim",
+			run: func(m *memoryStore) {
+				m.Begin(ctx, "key", "a", 0)
+				m.Release(ctx, "key", "other")
+			},
+			fingerprint: "a",
+			wantRecord:  Record{Fingerprint: "a"},
+		},
+		{
+			name: "abandoned claim is taken over after the lock timeout",
+			run: func(m *memoryStore) {
+				m.Begin(ctx, "key", "a", 0)
+				m.records["key"] = memoryRecord{Record: m.records["key"].Record, expiresAt: time.Now().Add(-time.Second)}
+			},
+			fingerprint: "b",
+			wantClaimed: true,
+			wantRecord:  Record{Fingerprint: "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := initMemory(Config{TTL: time.Hour, LockTimeout: time.Minute, CleanupInterval: time.Minute}).(*memoryStore)
+			defer m.Stop()
+			tt.run(m)
+
+			got, claimed, err := m.Begin(ctx, "key", tt.fingerprint, 0)
+			if err != nil {
+				t.Fatalf("Begin() error = %v", err)
+			}
+			if claimed != tt.wantClaimed {
+				t.Errorf("Begin() claimed = %v, want %v", claimed, tt.wantClaimed)
+			}
+
+			// only the claimer gets a token
+			if (got.Token != "") != claimed {
+				t.Errorf("Begin() token = %q, want one only when claimed", got.Token)
+			}
+			got.Token = ""
+			if !reflect.DeepEqual(got, tt.wantRecord) {
+				t.Errorf("Begin() = %+v, want %+v", got, tt.wantRecord)
+			}
+		})
+	}
+}
+
+func Test_memoryStore_takenOver(t *testing.T) {
+	ctx := context.Background()
+	resp := Response{StatusCode: http.StatusCreated, Body: []byte(`{"id":1}`)}
+
+	tests := []struct {
+		name string
+		run  func(m *memoryStore, token string) error
+	}{
+		{
+			name: "complete",
+			run: func(m *memoryStore, token string) error {
+				return m.Complete(ctx, "key", token, resp)
+			},
+		},
+		{
+			name: "release",
+			run: func(m *memoryStore, token string) error {
+				return m.Release(ctx, "key", token)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := initMemory(Config{TTL: time.Hour, LockTimeout: time.Minute, CleanupInterval: time.Minute}).(*memoryStore)
+			defer m.Stop()
+
+			// the first claim expires and a retry takes the key over
+			first, _, _ := m.Begin(ctx, "key", "a", 0)
+			m.records["key"] = memoryRecord{Record: m.records["key"].Record, token: first.Token, expiresAt: time.Now().Add(-time.Second)}
+			second, _, _ := m.Begin(ctx, "key", "a", 0)
+
+			if err := tt.run(m, first.Token); err == nil {
+				t.Errorf("%s with a claim taken over error = nil, want one", tt.name)
+			}
+
+			if rec := m.records["key"]; rec.token != second.Token || rec.Completed {
+				t.Errorf("the claim of the retry was changed, got %+v", rec)
+			}
+		})
+	}
+}
+
+func Test_memoryStore_Cleanup(t *testing.T) {
+	now := time.Now()
+	m := &memoryStore{records: map[string]memoryRecord{
+		"expired": {expiresAt: now.Add(-time.Second)},
+		"live":    {expiresAt: now.Add(time.Hour)},
+	}}
+
+	removed, err := m.Cleanup(context.Background())
+	if err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Cleanup() removed = %d, want 1", removed)
+	}
+	if _, ok := m.records["live"]; !ok {
+		t.Errorf("Cleanup() removed a live record")
+	}
+}
+
+func Test_memoryStore_cleanup(t *testing.T) {
+	m := initMemory(Config{TTL: time.Hour, LockTimeout: time.Minute, CleanupInterval: 10 * time.Millisecond}).(*memoryStore)
+	defer m.Stop()
+
+	m.mu.Lock()
+	m.records["expired"] = memoryRecord{expiresAt: time.Now().Add(-time.Second)}
+	m.mu.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		m.mu.Lock()
+		_, ok := m.records["expired"]
+		m.mu.Unlock()
+		if !ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expired record was not swept without the scheduler")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_memoryStore_Begin_lock(t *testing.T) {
+	tests := []struct {
+		name string
+		lock time.Duration
+		want time.Duration
+	}{
+		{name: "no lock asked", lock: 0, want: time.Minute},
+		{name: "shorter than the lock timeout", lock: time.Second, want: time.Minute},
+		{name: "longer than the lock timeout", lock: 2 * time.Minute, want: 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := initMemory(Config{TTL: time.Hour, LockTimeout: time.Minute, CleanupInterval: time.Minute}).(*memoryStore)
+			defer m.Stop()
+
+			begin := time.Now()
+			if _, _, err := m.Begin(context.Background(), "key", "a", tt.lock); err != nil {
+				t.Fatalf("Begin() error = %v", err)
+			}
+
+			if got := m.records["key"].expiresAt.Sub(begin); got < tt.want || got > tt.want+time.Second {
+				t.Errorf("Begin() holds the claim for %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_memoryStore_Stop(t *testing.T) {
+	m := initMemory(Config{TTL: time.Hour, LockTimeout: time.Minute, CleanupInterval: time.Millisecond}).(*memoryStore)
+
+	// stopping twice, e.g. by a test and the shutdown, is fine
+	m.Stop()
+	m.Stop()
+
+	select {
+	case <-m.stop:
+	default:
+		t.Fatalf("Stop() left the cleanup running")
+	}
+}
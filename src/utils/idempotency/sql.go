@@ -0,0 +1,209 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/parser"
+	"github.com/downsized-devs/sdk-go/sql"
+	"github.com/google/uuid"
+)
+
+// The sql store expects a table shared by every replica:
+//
+//	CREATE TABLE idempotency_record (
+//		idempotency_key VARCHAR(64) PRIMARY KEY,
+//		claim_token     VARCHAR(36) NOT NULL,
+//		fingerprint     VARCHAR(64) NOT NULL,
+//		completed       BOOLEAN NOT NULL,
+//		status_code     INT NOT NULL,
+//		header          TEXT NOT NULL,
+//		body            BLOB NOT NULL, -- BYTEA on postgres
+//		expires_at      BIGINT NOT NULL -- unix milliseconds
+//	);
+const (
+	readRecord          = `SELECT fingerprint, completed, status_code, header, body, expires_at FROM %s WHERE idempotency_key = ?`
+	readRecordForUpdate = readRecord + ` FOR UPDATE`
+	insertRecord        = `INSERT INTO %s (idempotency_key, claim_token, fingerprint, completed, status_code, header, body, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	claimRecord         = `UPDATE %s SET claim_token = ?, fingerprint = ?, completed = ?, status_code = ?, header = ?, body = ?, expires_at = ? WHERE idempotency_key = ?`
+	completeRecord      = `UPDATE %s SET completed = ?, status_code = ?, header = ?, body = ?, expires_at = ? WHERE idempotency_key = ? AND claim_token = ?`
+	deleteRecord        = `DELETE FROM %s WHERE idempotency_key = ? AND claim_token = ?`
+	deleteExpired       = `DELETE FROM %s WHERE expires_at <= ?`
+)
+
+type sqlRecord struct {
+	Fingerprint string `db:"fingerprint"`
+	Completed   bool   `db:"completed"`
+	StatusCode  int    `db:"status_code"`
+	Header      string `db:"header"`
+	Body        []byte `db:"body"`
+	ExpiresAt   int64  `db:"expires_at"`
+}
+
+type sqlStore struct {
+	db          sql.Interface
+	json        parser.JsonInterface
+	table       string
+	ttl         time.Duration
+	lockTimeout time.Duration
+}
+
+func initSQL(cfg Config, json parser.JsonInterface, db sql.Interface) Interface {
+	return &sqlStore{
+		db:          db,
+		json:        json,
+		table:       cfg.Table,
+		ttl:         cfg.TTL,
+		lockTimeout: cfg.LockTimeout,
+	}
+}
+
+func (s *sqlStore) Begin(ctx context.Context, key, fingerprint string, lock time.Duration) (Record, bool, error) {
+	rec, claimed, err := s.claim(ctx, key, fingerprint, max(s.lockTimeout, lock))
+	if err == nil {
+		return rec, claimed, nil
+	}
+
+	// another replica may have inserted the same key between our read and insert,
+	// in that case its record is the one to report
+	existing := sqlRecord{}
+	if getErr := s.db.Leader().Get(ctx, "rIdempotencyRecord", s.db.Leader().Rebind(fmt.Sprintf(readRecord, s.table)), &existing, key); getErr != nil {
+		return Record{}, false, err
+	}
+
+	if time.Now().UnixMilli() >= existing.ExpiresAt {
+		return Record{}, false, err
+	}
+
+	rec, convErr := s.toRecord(existing)
+	if convErr != nil {
+		return Record{}, false, convErr
+	}
+
+	return rec, false, nil
+}
+
+func (s *sqlStore) claim(ctx context.Context, key, fingerprint string, lock time.Duration) (Record, bool, error) {
+	tx, err := s.db.Leader().BeginTx(ctx, "txIdempotencyBegin", sql.TxOptions{})
+	if err != nil {
+		return Record{}, false, errors.NewWithCode(codes.CodeSQLTxBegin, "%s", err.Error())
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	existing := sqlRecord{}
+	found := true
+	err = tx.Get("rIdempotencyRecordForUpdate", tx.Rebind(fmt.Sprintf(readRecordForUpdate, s.table)), &existing, key)
+	if errors.Is(err, sql.ErrNotFound) {
+		found = false
+	} else if err != nil {
+		return Record{}, false, errors.NewWithCode(codes.CodeSQLRead, "%s", err.Error())
+	}
+
+	if found && now.UnixMilli() < existing.ExpiresAt {
+		rec, err := s.toRecord(existing)
+		return rec, false, err
+	}
+
+	token := uuid.NewString()
+	expiresAt := now.Add(lock).UnixMilli()
+	if found {
+		_, err = tx.Exec("uIdempotencyRecordClaim", tx.Rebind(fmt.Sprintf(claimRecord, s.table)),
+			token, fingerprint, false, 0, "{}", []byte{}, expiresAt, key)
+	} else {
+		_, err = tx.Exec("iIdempotencyRecord", tx.Rebind(fmt.Sprintf(insertRecord, s.table)),
+			key, token, fingerprint, false, 0, "{}", []byte{}, expiresAt)
+	}
+	if err != nil {
+		return Record{}, false, errors.NewWithCode(codes.CodeSQLTxExec, "%s", err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Record{}, false, errors.NewWithCode(codes.CodeSQLTxCommit, "%s", err.Error())
+	}
+
+	return Record{Token: token, Fingerprint: fingerprint}, true, nil
+}
+
+func (s *sqlStore) Complete(ctx context.Context, key, token string, resp Response) error {
+	header, err := s.json.Marshal(resp.Header)
+	if err != nil {
+		return errors.NewWithCode(codes.CodeJSONMarshalError, "%s", err.Error())
+	}
+
+	res, err := s.db.Leader().Exec(ctx, "uIdempotencyRecordComplete", s.db.Leader().Rebind(fmt.Sprintf(completeRecord, s.table)),
+		true, resp.StatusCode, string(header), resp.Body, time.Now().Add(s.ttl).UnixMilli(), key, token)
+	if err != nil {
+		return errors.NewWithCode(codes.CodeSQLTxExec, "%s", err.Error())
+	}
+
+	// no row holding the token means the claim was taken over
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.NewWithCode(codes.CodeSQL, "%s", err.Error())
+	}
+
+	if affected == 0 {
+		return errClaimLost(key)
+	}
+
+	return nil
+}
+
+func (s *sqlStore) Release(ctx context.Context, key, token string) error {
+	res, err := s.db.Leader().Exec(ctx, "dIdempotencyRecord", s.db.Leader().Rebind(fmt.Sprintf(deleteRecord, s.table)), key, token)
+	if err != nil {
+		return errors.NewWithCode(codes.CodeSQLTxExec, "%s", err.Error())
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.NewWithCode(codes.CodeSQL, "%s", err.Error())
+	}
+
+	if affected == 0 {
+		return errClaimLost(key)
+	}
+
+	return nil
+}
+
+func (s *sqlStore) Stop() {}
+
+func (s *sqlStore) Cleanup(ctx context.Context) (int64, error) {
+	res, err := s.db.Leader().Exec(ctx, "dIdempotencyRecordExpired", s.db.Leader().Rebind(fmt.Sprintf(deleteExpired, s.table)), time.Now().UnixMilli())
+	if err != nil {
+		return 0, errors.NewWithCode(codes.CodeSQLTxExec, "%s", err.Error())
+	}
+
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.NewWithCode(codes.CodeSQL, "%s", err.Error())
+	}
+
+	return removed, nil
+}
+
+func (s *sqlStore) toRecord(r sqlRecord) (Record, error) {
+	rec := Record{
+		Fingerprint: r.Fingerprint,
+		Completed:   r.Completed,
+		Response: Response{
+			StatusCode: r.StatusCode,
+			Header:     http.Header{},
+			Body:       r.Body,
+		},
+	}
+
+	if r.Header != "" {
+		if err := s.json.Unmarshal([]byte(r.Header), &rec.Response.Header); err != nil {
+			return rec, errors.NewWithCode(codes.CodeJSONUnmarshalError, "%s", err.Error())
+		}
+	}
+
+	return rec, nil
+}
@@ -0,0 +1,293 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/parser"
+	"github.com/downsized-devs/sdk-go/sql"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	mock_sql "github.com/downsized-devs/sdk-go/tests/mock/sql"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_sqlStore_Begin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	dbMock := mock_sql.NewMockInterface(ctrl)
+	leaderMock := mock_sql.NewMockCommand(ctrl)
+	txMock := mock_sql.NewMockCommandTx(ctrl)
+	json := parser.InitParser(logMock, parser.Options{}).JsonParser()
+
+	type mockFields struct {
+		leader *mock_sql.MockCommand
+		tx     *mock_sql.MockCommandTx
+	}
+
+	mocks := mockFields{
+		leader: leaderMock,
+		tx:     txMock,
+	}
+
+	live := time.Now().Add(time.Hour).UnixMilli()
+	expired := time.Now().Add(-time.Second).UnixMilli()
+	completed := sqlRecord{
+		Fingerprint: "a",
+		Completed:   true,
+		StatusCode:  http.StatusCreated,
+		Header:      `{"Content-Type":["application/json"]}`,
+		Body:        []byte(`{"id":1}`),
+		ExpiresAt:   live,
+	}
+	completedRecord := Record{
+		Fingerprint: "a",
+		Completed:   true,
+		Response: Response{
+			StatusCode: http.StatusCreated,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       []byte(`{"id":1}`),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		mockFunc    func(m mockFields)
+		want        Record
+		wantClaimed bool
+		wantErr     bool
+	}{
+		{
+			name: "new key is inserted",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txIdempotencyBegin", gomock.Any()).Return(m.tx, nil)
+				m.tx.EXPECT().Get("rIdempotencyRecordForUpdate", gomock.Any(), gomock.Any(), "key").Return(sql.ErrNotFound)
+				m.tx.EXPECT().Exec("iIdempotencyRecord", gomock.Any(), gomock.Any()).Return(driver.RowsAffected(1), nil)
+				m.tx.EXPECT().Commit().Return(nil)
+			},
+			want:        Record{Fingerprint: "a"},
+			wantClaimed: true,
+		},
+		{
+			name: "expired key is claimed again",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txIdempotencyBegin", gomock.Any()).Return(m.tx, nil)
+				m.tx.EXPECT().Get("rIdempotencyRecordForUpdate", gomock.Any(), gomock.Any(), "key").SetArg(2, sqlRecord{Fingerprint: "b", ExpiresAt: expired})
+				m.tx.EXPECT().Exec("uIdempotencyRecordClaim", gomock.Any(), gomock.Any()).Return(driver.RowsAffected(1), nil)
+				m.tx.EXPECT().Commit().Return(nil)
+			},
+			want:        Record{Fingerprint: "a"},
+			wantClaimed: true,
+		},
+		{
+			name: "completed key returns the stored response",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txIdempotencyBegin", gomock.Any()).Return(m.tx, nil)
+				m.tx.EXPECT().Get("rIdempotencyRecordForUpdate", gomock.Any(), gomock.Any(), "key").SetArg(2, completed)
+			},
+			want: completedRecord,
+		},
+		{
+			name: "insert lost to another replica reports its record",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txIdempotencyBegin", gomock.Any()).Return(m.tx, nil)
+				m.tx.EXPECT().Get("rIdempotencyRecordForUpdate", gomock.Any(), gomock.Any(), "key").Return(sql.ErrNotFound)
+				m.tx.EXPECT().Exec("iIdempotencyRecord", gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("duplicate key"))
+				m.leader.EXPECT().Get(gomock.Any(), "rIdempotencyRecord", gomock.Any(), gomock.Any(), "key").SetArg(3, completed)
+			},
+			want: completedRecord,
+		},
+		{
+			name: "read error",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txIdempotencyBegin", gomock.Any()).Return(m.tx, nil)
+				m.tx.EXPECT().Get("rIdempotencyRecordForUpdate", gomock.Any(), gomock.Any(), "key").Return(fmt.Errorf("connection refused"))
+				m.leader.EXPECT().Get(gomock.Any(), "rIdempotencyRecord", gomock.Any(), gomock.Any(), "key").Return(fmt.Errorf("connection refused"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMock.EXPECT().Leader().Return(leaderMock).AnyTimes()
+			leaderMock.EXPECT().Rebind(gomock.Any()).DoAndReturn(func(q string) string { return q }).AnyTimes()
+			txMock.EXPECT().Rebind(gomock.Any()).DoAndReturn(func(q string) string { return q }).AnyTimes()
+			txMock.EXPECT().Rollback().AnyTimes()
+			tt.mockFunc(mocks)
+
+			s := initSQL(Config{Table: defaultTable, TTL: time.Hour, LockTimeout: time.Minute}, json, dbMock)
+			got, claimed, err := s.Begin(context.Background(), "key", "a", 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Begin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if claimed != tt.wantClaimed {
+				t.Errorf("Begin() claimed = %v, want %v", claimed, tt.wantClaimed)
+			}
+
+			// only the claimer gets a token
+			if (got.Token != "") != claimed {
+				t.Errorf("Begin() token = %q, want one only when claimed", got.Token)
+			}
+			got.Token = ""
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Begin() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sqlStore_Complete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	dbMock := mock_sql.NewMockInterface(ctrl)
+	leaderMock := mock_sql.NewMockCommand(ctrl)
+	json := parser.InitParser(logMock, parser.Options{}).JsonParser()
+
+	resp := Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       []byte(`{"id":1}`),
+	}
+
+	tests := []struct {
+		name     string
+		mockFunc func()
+		wantErr  bool
+	}{
+		{
+			name: "stores the response",
+			mockFunc: func() {
+				leaderMock.EXPECT().Exec(gomock.Any(), "uIdempotencyRecordComplete", gomock.Any(),
+					true, http.StatusCreated, `{"Content-Type":["application/json"]}`, resp.Body, gomock.Any(), "key", "token").
+					Return(driver.RowsAffected(1), nil)
+			},
+		},
+		{
+			name: "claim taken over",
+			mockFunc: func() {
+				leaderMock.EXPECT().Exec(gomock.Any(), "uIdempotencyRecordComplete", gomock.Any(), gomock.Any()).Return(driver.RowsAffected(0), nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "exec error",
+			mockFunc: func() {
+				leaderMock.EXPECT().Exec(gomock.Any(), "uIdempotencyRecordComplete", gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("connection refused"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMock.EXPECT().Leader().Return(leaderMock).AnyTimes()
+			leaderMock.EXPECT().Rebind(gomock.Any()).DoAndReturn(func(q string) string { return q }).AnyTimes()
+			tt.mockFunc()
+
+			s := initSQL(Config{Table: defaultTable, TTL: time.Hour}, json, dbMock)
+			if err := s.Complete(context.Background(), "key", "token", resp); (err != nil) != tt.wantErr {
+				t.Errorf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_sqlStore_Release(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dbMock := mock_sql.NewMockInterface(ctrl)
+	leaderMock := mock_sql.NewMockCommand(ctrl)
+
+	tests := []struct {
+		name     string
+		mockFunc func()
+		wantErr  bool
+	}{
+		{
+			name: "drops the claim",
+			mockFunc: func() {
+				leaderMock.EXPECT().Exec(gomock.Any(), "dIdempotencyRecord", gomock.Any(), "key", "token").Return(driver.RowsAffected(1), nil)
+			},
+		},
+		{
+			name: "claim taken over",
+			mockFunc: func() {
+				leaderMock.EXPECT().Exec(gomock.Any(), "dIdempotencyRecord", gomock.Any(), "key", "token").Return(driver.RowsAffected(0), nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "exec error",
+			mockFunc: func() {
+				leaderMock.EXPECT().Exec(gomock.Any(), "dIdempotencyRecord", gomock.Any(), "key", "token").Return(nil, fmt.Errorf("connection refused"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMock.EXPECT().Leader().Return(leaderMock).AnyTimes()
+			leaderMock.EXPECT().Rebind(gomock.Any()).DoAndReturn(func(q string) string { return q }).AnyTimes()
+			tt.mockFunc()
+
+			s := initSQL(Config{Table: defaultTable}, nil, dbMock)
+			if err := s.Release(context.Background(), "key", "token"); (err != nil) != tt.wantErr {
+				t.Errorf("Release() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_sqlStore_Cleanup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dbMock := mock_sql.NewMockInterface(ctrl)
+	leaderMock := mock_sql.NewMockCommand(ctrl)
+
+	tests := []struct {
+		name     string
+		mockFunc func()
+		want     int64
+		wantErr  bool
+	}{
+		{
+			name: "removes expired records",
+			mockFunc: func() {
+				leaderMock.EXPECT().Exec(gomock.Any(), "dIdempotencyRecordExpired", gomock.Any(), gomock.Any()).Return(driver.RowsAffected(2), nil)
+			},
+			want: 2,
+		},
+		{
+			name: "exec error",
+			mockFunc: func() {
+				leaderMock.EXPECT().Exec(gomock.Any(), "dIdempotencyRecordExpired", gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("connection refused"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMock.EXPECT().Leader().Return(leaderMock).AnyTimes()
+			leaderMock.EXPECT().Rebind(gomock.Any()).DoAndReturn(func(q string) string { return q }).AnyTimes()
+			tt.mockFunc()
+
+			s := initSQL(Config{Table: defaultTable}, nil, dbMock)
+			got, err := s.Cleanup(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Cleanup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Cleanup() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	PanicSourceHTTP      string = "http"
+	PanicSourceScheduler string = "scheduler"
+
+	schedulerResultSuccess string = "success"
+	schedulerResultFailure string = "failure"
+)
+
+type Interface interface {
+	IsEnabled() bool
+	// HTTP Handler exposing all collected metrics in prometheus text format
+	Handler() http.Handler
+	// HTTP Metrics
+	HTTPRequestInFlight(route, method string) func()
+	HTTPRequestObserve(route, method string, status int, appCode codes.Code, duration time.Duration)
+	// Scheduler Metrics
+	SchedulerRunObserve(name string, duration time.Duration, err error)
+	// PanicRecovered counts a panic caught in an http route or a scheduler task
+	PanicRecovered(source, name string)
+}
+
+type Config struct {
+	Enabled bool
+}
+
+type metrics struct {
+	cfg      Config
+	registry *prometheus.Registry
+
+	httpRequestTotal     *prometheus.CounterVec
+	httpRequestDuration  *prometheus.HistogramVec
+	httpRequestInFlight  *prometheus.GaugeVec
+	schedulerRunTotal    *prometheus.CounterVec
+	schedulerRunDuration *prometheus.HistogramVec
+	schedulerRunFailure  *prometheus.CounterVec
+	panicRecovered       *prometheus.CounterVec
+}
+
+func Init(cfg Config) Interface {
+	m := &metrics{cfg: cfg}
+	if !cfg.Enabled {
+		return m
+	}
+
+	m.registry = prometheus.NewRegistry()
+	m.httpRequestTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests by route, method, status and app response code",
+		},
+		[]string{"route", "method", "status", "app_code"},
+	)
+	m.httpRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"route", "method"},
+	)
+	m.httpRequestInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served",
+		},
+		[]string{"route", "method"},
+	)
+	m.schedulerRunTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "scheduler_runs_total",
+			Help: "Number of scheduler runs by result",
+		},
+		[]string{"scheduler_name", "result"},
+	)
+	m.schedulerRunDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "scheduler_run_duration_seconds",
+			Help:    "Duration of scheduler runs",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"scheduler_name"},
+	)
+	m.schedulerRunFailure = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "scheduler_run_failures_total",
+			Help: "Number of failed scheduler runs",
+		},
+		[]string{"scheduler_name"},
+	)
+	m.panicRecovered = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "panics_recovered_total",
+			Help: "Number of recovered panics by source and route or scheduler name",
+		},
+		[]string{"source", "name"},
+	)
+
+	m.registry.MustRegister(
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewGoCollector(),
+		m.httpRequestTotal,
+		m.httpRequestDuration,
+		m.httpRequestInFlight,
+		m.schedulerRunTotal,
+		m.schedulerRunDuration,
+		m.schedulerRunFailure,
+		m.panicRecovered,
+	)
+
+	return m
+}
+
+func (m *metrics) IsEnabled() bool {
+	return m.cfg.Enabled
+}
+
+func (m *metrics) Handler() http.Handler {
+	if !m.cfg.Enabled {
+		return http.NotFoundHandler()
+	}
+
+	return promhttp.InstrumentMetricHandler(m.registry, promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// HTTPRequestInFlight increases the in flight gauge and returns the func to decrease it back
+func (m *metrics) HTTPRequestInFlight(route, method string) func() {
+	if !m.cfg.Enabled {
+		return func() {}
+	}
+
+	gauge := m.httpRequestInFlight.WithLabelValues(route, method)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+func (m *metrics) HTTPRequestObserve(route, method string, status int, appCode codes.Code, duration time.Duration) {
+	if !m.cfg.Enabled {
+		return
+	}
+
+	code := ""
+	if appCode > 0 {
+		code = strconv.FormatUint(uint64(appCode), 10)
+	}
+
+	m.httpRequestTotal.WithLabelValues(route, method, strconv.Itoa(status), code).Inc()
+	m.httpRequestDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+func (m *metrics) SchedulerRunObserve(name string, duration time.Duration, err error) {
+	if !m.cfg.Enabled {
+		return
+	}
+
+	result := schedulerResultSuccess
+	if err != nil {
+		result = schedulerResultFailure
+		m.schedulerRunFailure.WithLabelValues(name).Inc()
+	}
+
+	m.schedulerRunTotal.WithLabelValues(name, result).Inc()
+	m.schedulerRunDuration.WithLabelValues(name).Observe(duration.Seconds())
+}
+
+func (m *metrics) PanicRecovered(source, name string) {
+	if !m.cfg.Enabled {
+		return
+	}
+
+	m.panicRecovered.WithLabelValues(source, name).Inc()
+}
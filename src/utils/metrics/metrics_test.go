@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+)
+
+// scrape reads the exposition of m the way prometheus does
+func scrape(t *testing.T, m Interface) (int, string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+
+	return rec.Code, string(body)
+}
+
+func Test_metrics_Observe(t *testing.T) {
+	success := fmt.Sprint(uint64(codes.CodeSuccess))
+
+	tests := []struct {
+		name    string
+		observe func(m Interface)
+		want    []string
+	}{
+		{
+			name: "requests by route, status and app code",
+			observe: func(m Interface) {
+				m.HTTPRequestObserve("/v1/items/:id", http.MethodGet, http.StatusOK, codes.CodeSuccess, time.Millisecond)
+				m.HTTPRequestObserve("/v1/items/:id", http.MethodGet, http.StatusOK, codes.CodeSuccess, time.Millisecond)
+				m.HTTPRequestObserve("/v1/items/:id", http.MethodGet, http.StatusNotFound, 0, time.Millisecond)
+				m.HTTPRequestObserve("/v1/items", http.MethodPost, http.StatusCreated, codes.CodeSuccess, time.Millisecond)
+			},
+			want: []string{
+				`http_requests_total{app_code="` + success + `",method="GET",route="/v1/items/:id",status="200"} 2`,
+				`http_requests_total{app_code="",method="GET",route="/v1/items/:id",status="404"} 1`,
+				`http_requests_total{app_code="` + success + `",method="POST",route="/v1/items",status="201"} 1`,
+				`http_request_duration_seconds_count{method="GET",route="/v1/items/:id"} 3`,
+				`http_request_duration_seconds_count{method="POST",route="/v1/items"} 1`,
+			},
+		},
+		{
+			name: "in flight requests go back to zero",
+			observe: func(m Interface) {
+				first := m.HTTPRequestInFlight("/v1/items", http.MethodGet)
+				second := m.HTTPRequestInFlight("/v1/items", http.MethodGet)
+				first()
+				second()
+			},
+			want: []string{
+				`http_requests_in_flight{method="GET",route="/v1/items"} 0`,
+			},
+		},
+		{
+			name: "scheduler runs by result",
+			observe: func(m Interface) {
+				m.SchedulerRunObserve("cleanup", time.Millisecond, nil)
+				m.SchedulerRunObserve("cleanup", time.Millisecond, errors.New("failed"))
+			},
+			want: []string{
+				`scheduler_runs_total{result="success",scheduler_name="cleanup"} 1`,
+				`scheduler_runs_total{result="failure",scheduler_name="cleanup"} 1`,
+				`scheduler_run_failures_total{scheduler_name="cleanup"} 1`,
+				`scheduler_run_duration_seconds_count{scheduler_name="cleanup"} 2`,
+			},
+		},
+		{
+			name: "recovered panics by source and name",
+			observe: func(m Interface) {
+				m.PanicRecovered(PanicSourceHTTP, "/v1/items/:id")
+				m.PanicRecovered(PanicSourceScheduler, "cleanup")
+				m.PanicRecovered(PanicSourceScheduler, "cleanup")
+			},
+			want: []string{
+				`panics_recovered_total{name="/v1/items/:id",source="http"} 1`,
+				`panics_recovered_total{name="cleanup",source="scheduler"} 2`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Init(Config{Enabled: true})
+			tt.observe(m)
+
+			code, body := scrape(t, m)
+			if code != http.StatusOK {
+				t.Fatalf("Handler() status = %d, want %d", code, http.StatusOK)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want+"\n") {
+					t.Errorf("Handler() body misses %s", want)
+				}
+			}
+		})
+	}
+}
+
+func Test_metrics_disabled(t *testing.T) {
+	m := Init(Config{})
+	if m.IsEnabled() {
+		t.Fatalf("IsEnabled() = true, want false")
+	}
+
+	// none of these may touch the collectors a disabled config never creates
+	m.HTTPRequestInFlight("/v1/items", http.MethodGet)()
+	m.HTTPRequestObserve("/v1/items", http.MethodGet, http.StatusOK, codes.CodeSuccess, time.Millisecond)
+	m.SchedulerRunObserve("cleanup", time.Millisecond, errors.New("failed"))
+	m.PanicRecovered(PanicSourceScheduler, "cleanup")
+
+	if code, _ := scrape(t, m); code != http.StatusNotFound {
+		t.Errorf("Handler() status = %d, want %d", code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,442 @@
+package queryspec
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/template-service-go/src/utils/fielderror"
+)
+
+type Type string
+
+const (
+	TypeString Type = "string"
+	TypeInt    Type = "int"
+	TypeFloat  Type = "float"
+	TypeBool   Type = "bool"
+	TypeTime   Type = "time"
+)
+
+type Operator string
+
+const (
+	OpEq   Operator = "eq"
+	OpNe   Operator = "ne"
+	OpGt   Operator = "gt"
+	OpGte  Operator = "gte"
+	OpLt   Operator = "lt"
+	OpLte  Operator = "lte"
+	OpIn   Operator = "in"
+	OpNin  Operator = "nin"
+	OpLike Operator = "like"
+	OpNull Operator = "null"
+)
+
+const (
+	SortParam string = "sortBy"
+
+	sortDesc       string = "-"
+	sortAsc        string = "+"
+	valueSep       string = ","
+	dateLayout     string = "2006-01-02"
+	defaultMaxSort int    = 3
+)
+
+var (
+	filterPattern = regexp.MustCompile(`^([A-Za-z0-9_.]+)\[([a-z]+)\]$`)
+	columnPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+	defaultOperators = map[Type][]Operator{
+		TypeString: {OpEq, OpNe, OpIn, OpNin, OpLike, OpNull},
+		TypeInt:    {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNin, OpNull},
+		TypeFloat:  {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNin, OpNull},
+		TypeTime:   {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNin, OpNull},
+		TypeBool:   {OpEq, OpNe, OpNull},
+	}
+
+	comparators = map[Operator]string{
+		OpEq:  "=",
+		OpNe:  "<>",
+		OpGt:  ">",
+		OpGte: ">=",
+		OpLt:  "<",
+		OpLte: "<=",
+	}
+)
+
+// Field declares a field clients may sort or filter on. Column is the SQL column it maps to and
+// defaults to Name. Operators limits the filters allowed, by default every operator fitting Type
+type Field struct {
+	Name       string
+	Column     string
+	Type       Type
+	Sortable   bool
+	Filterable bool
+	Operators  []Operator
+}
+
+// Schema is what a handler accepts. DefaultSort uses the sortBy syntax, e.g. -created_at,
+// and MaxSort caps the number of sort fields, 3 when unset
+type Schema struct {
+	Fields      []Field
+	DefaultSort []string
+	MaxSort     int
+}
+
+type Sort struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+type Filter struct {
+	Field    string
+	Column   string
+	Operator Operator
+	Values   []interface{}
+}
+
+// Spec is the validated sort and filter of a request
+type Spec struct {
+	Sort    []Sort
+	Filters []Filter
+}
+
+// Clause holds the SQL fragments of a spec, both may be empty
+type Clause struct {
+	Where   string
+	OrderBy string
+	Args    []interface{}
+}
+
+// MustSchema panics when the schema is invalid, it is meant for schemas declared as package
+// variables so a mistake stops the service at startup instead of failing the requests using it
+func MustSchema(s Schema) Schema {
+	if err := s.Validate(); err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// Validate reports the first mistake in the declaration: a field without a name or declared twice,
+// a column that is not a plain column name, an unknown type, an operator that does not fit the
+// type or a default sort on a field that is not sortable
+func (s Schema) Validate() error {
+	fields := s.fields()
+
+	seen := map[string]bool{}
+	for i, f := range s.Fields {
+		if f.Name == "" {
+			return errors.NewWithCode(codes.CodeInvalidValue, "queryspec field %d has no name", i)
+		}
+
+		if seen[f.Name] {
+			return errors.NewWithCode(codes.CodeInvalidValue, "queryspec field %q is declared twice", f.Name)
+		}
+		seen[f.Name] = true
+
+		if column := fields[f.Name].Column; !columnPattern.MatchString(column) {
+			return errors.NewWithCode(codes.CodeInvalidValue, "queryspec field %q maps to the invalid column %q", f.Name, column)
+		}
+
+		ops, ok := defaultOperators[f.Type]
+		if !ok {
+			return errors.NewWithCode(codes.CodeInvalidValue, "queryspec field %q has the unknown type %q", f.Name, f.Type)
+		}
+
+		for _, op := range f.Operators {
+			if !allowed(Field{Type: f.Type}, op) {
+				return errors.NewWithCode(codes.CodeInvalidValue, "queryspec operator %q does not apply to the %s field %q, use one of %v", op, f.Type, f.Name, ops)
+			}
+		}
+	}
+
+	for _, raw := range s.DefaultSort {
+		if _, err := parseSort(fields, raw); err != nil {
+			return errors.NewWithCode(codes.CodeInvalidValue, "queryspec default sort: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// fields indexes the declared fields by name with their column defaulted
+func (s Schema) fields() map[string]Field {
+	fields := make(map[string]Field, len(s.Fields))
+	for _, f := range s.Fields {
+		if f.Name == "" {
+			continue
+		}
+
+		if f.Column == "" {
+			f.Column = f.Name
+		}
+
+		fields[f.Name] = f
+	}
+
+	return fields
+}
+
+// Parse reads sortBy=-created_at,id and filters like status[in]=1,2 or name=foo from the query.
+// Params that are neither sortBy nor a declared field, e.g. page, are left alone. Every invalid
+// field is reported at once in a fielderror. An invalid schema is a server error, not the client's
+func (s Schema) Parse(query url.Values) (Spec, error) {
+	spec := Spec{}
+	invalid := []fielderror.Field{}
+
+	if err := s.Validate(); err != nil {
+		return spec, errors.NewWithCode(codes.CodeInternalServerError, "%s", err.Error())
+	}
+	fields := s.fields()
+
+	sorts := s.DefaultSort
+	if raw, ok := query[SortParam]; ok {
+		sorts = splitValues(raw)
+	}
+
+	maxSort := s.MaxSort
+	if maxSort <= 0 {
+		maxSort = defaultMaxSort
+	}
+
+	if len(sorts) > maxSort {
+		invalid = append(invalid, fielderror.Field{Field: SortParam, Message: fmt.Sprintf("at most %d sort fields are allowed", maxSort)})
+		sorts = sorts[:maxSort]
+	}
+
+	for _, raw := range sorts {
+		o, err := parseSort(fields, raw)
+		if err != nil {
+			invalid = append(invalid, fielderror.Field{Field: SortParam, Message: err.Error()})
+			continue
+		}
+
+		spec.Sort = append(spec.Sort, o)
+	}
+
+	for _, key := range sortedKeys(query) {
+		if key == SortParam {
+			continue
+		}
+
+		name, op := key, OpEq
+		if m := filterPattern.FindStringSubmatch(key); m != nil {
+			name, op = m[1], Operator(m[2])
+		} else if _, ok := fields[key]; !ok {
+			continue
+		}
+
+		filter, err := parseFilter(fields, name, op, query[key])
+		if err != nil {
+			invalid = append(invalid, fielderror.Field{Field: key, Message: err.Error()})
+			continue
+		}
+
+		spec.Filters = append(spec.Filters, filter)
+	}
+
+	if len(invalid) > 0 {
+		return spec, fielderror.New(invalid)
+	}
+
+	return spec, nil
+}
+
+func parseSort(fields map[string]Field, raw string) (Sort, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(raw, sortDesc), sortAsc)
+
+	f, ok := fields[name]
+	if !ok || !f.Sortable {
+		return Sort{}, errors.NewWithCode(codes.CodeInvalidValue, "%q is not sortable", name)
+	}
+
+	return Sort{Field: f.Name, Column: f.Column, Desc: strings.HasPrefix(raw, sortDesc)}, nil
+}
+
+func parseFilter(fields map[string]Field, name string, op Operator, raw []string) (Filter, error) {
+	f, ok := fields[name]
+	if !ok || !f.Filterable {
+		return Filter{}, errors.NewWithCode(codes.CodeInvalidValue, "%q is not filterable", name)
+	}
+
+	if !allowed(f, op) {
+		return Filter{}, errors.NewWithCode(codes.CodeInvalidValue, "operator %q is not allowed on %q", op, name)
+	}
+
+	filter := Filter{Field: f.Name, Column: f.Column, Operator: op}
+
+	values := raw
+	switch op {
+	case OpIn, OpNin:
+		values = splitValues(raw)
+	case OpNull:
+		if len(raw) != 1 {
+			return filter, errors.NewWithCode(codes.CodeInvalidValue, "expects true or false")
+		}
+
+		isNull, err := strconv.ParseBool(raw[0])
+		if err != nil {
+			return filter, errors.NewWithCode(codes.CodeInvalidValue, "expects true or false")
+		}
+
+		filter.Values = []interface{}{isNull}
+		return filter, nil
+	default:
+		if len(raw) != 1 {
+			return filter, errors.NewWithCode(codes.CodeInvalidValue, "expects a single value")
+		}
+	}
+
+	if len(values) == 0 {
+		return filter, errors.NewWithCode(codes.CodeInvalidValue, "expects at least one value")
+	}
+
+	for _, v := range values {
+		parsed, err := parseValue(f.Type, v)
+		if err != nil {
+			return filter, err
+		}
+
+		if op == OpLike {
+			parsed = likePattern(v)
+		}
+
+		filter.Values = append(filter.Values, parsed)
+	}
+
+	return filter, nil
+}
+
+func allowed(f Field, op Operator) bool {
+	ops := f.Operators
+	if len(ops) == 0 {
+		ops = defaultOperators[f.Type]
+	}
+
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseValue(t Type, v string) (interface{}, error) {
+	switch t {
+	case TypeInt:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, errors.NewWithCode(codes.CodeInvalidValue, "%q is not an integer", v)
+		}
+		return i, nil
+	case TypeFloat:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.NewWithCode(codes.CodeInvalidValue, "%q is not a number", v)
+		}
+		return f, nil
+	case TypeBool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.NewWithCode(codes.CodeInvalidValue, "%q is not a boolean", v)
+		}
+		return b, nil
+	case TypeTime:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse(dateLayout, v); err == nil {
+			return t, nil
+		}
+		return nil, errors.NewWithCode(codes.CodeInvalidValue, "%q is not an RFC 3339 time or a date", v)
+	default:
+		return v, nil
+	}
+}
+
+// Render builds the parameterized SQL of the spec with '?' placeholders, rebind them for the driver if needed.
+// Filters are joined with AND and the values never end up in the query text
+func (s Spec) Render() Clause {
+	clause := Clause{}
+
+	conds := make([]string, 0, len(s.Filters))
+	for _, f := range s.Filters {
+		conds = append(conds, f.render(&clause.Args))
+	}
+	clause.Where = strings.Join(conds, " AND ")
+
+	order := make([]string, 0, len(s.Sort))
+	for _, o := range s.Sort {
+		dir := "ASC"
+		if o.Desc {
+			dir = "DESC"
+		}
+		order = append(order, fmt.Sprintf("%s %s", o.Column, dir))
+	}
+	clause.OrderBy = strings.Join(order, ", ")
+
+	return clause
+}
+
+func (f Filter) render(args *[]interface{}) string {
+	switch f.Operator {
+	case OpNull:
+		if isNull, _ := f.Values[0].(bool); isNull {
+			return fmt.Sprintf("%s IS NULL", f.Column)
+		}
+		return fmt.Sprintf("%s IS NOT NULL", f.Column)
+	case OpIn, OpNin:
+		*args = append(*args, f.Values...)
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(f.Values)), ", ")
+		if f.Operator == OpNin {
+			return fmt.Sprintf("%s NOT IN (%s)", f.Column, placeholders)
+		}
+		return fmt.Sprintf("%s IN (%s)", f.Column, placeholders)
+	case OpLike:
+		*args = append(*args, f.Values[0])
+		return fmt.Sprintf("%s LIKE ? ESCAPE '!'", f.Column)
+	}
+
+	*args = append(*args, f.Values[0])
+	return fmt.Sprintf("%s %s ?", f.Column, comparators[f.Operator])
+}
+
+// likePattern matches the value anywhere, escaping the wildcards the client sent
+func likePattern(v string) string {
+	v = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(v)
+	return "%" + v + "%"
+}
+
+func splitValues(raw []string) []string {
+	values := []string{}
+	for _, r := range raw {
+		for _, v := range strings.Split(r, valueSep) {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+
+	return values
+}
+
+// sortedKeys keeps the rendered filters and reported fields in a stable order
+func sortedKeys(query url.Values) []string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
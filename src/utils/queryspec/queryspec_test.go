@@ -0,0 +1,218 @@
+package queryspec
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/template-service-go/src/utils/fielderror"
+)
+
+var testSchema = MustSchema(Schema{
+	Fields: []Field{
+		{Name: "name", Column: "u.name", Type: TypeString, Sortable: true, Filterable: true},
+		{Name: "age", Type: TypeInt, Sortable: true, Filterable: true},
+		{Name: "score", Type: TypeFloat, Filterable: true, Operators: []Operator{OpGte, OpLte}},
+		{Name: "active", Type: TypeBool, Filterable: true},
+		{Name: "created_at", Type: TypeTime, Sortable: true, Filterable: true},
+		{Name: "id", Type: TypeInt, Sortable: true},
+	},
+	DefaultSort: []string{"-created_at", "id"},
+	MaxSort:     2,
+})
+
+func TestSchema_Parse(t *testing.T) {
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		query       string
+		want        Spec
+		wantInvalid []string
+	}{
+		{
+			name:  "default sort, unknown params left alone",
+			query: "page=2&limit=10",
+			want: Spec{
+				Sort: []Sort{{Field: "created_at", Column: "created_at", Desc: true}, {Field: "id", Column: "id"}},
+			},
+		},
+		{
+			name:  "sort and filters",
+			query: "sortBy=name,-age&name=bob&age[gte]=18&age[in]=18,21&active[null]=false&created_at[lt]=2024-05-06",
+			want: Spec{
+				Sort: []Sort{{Field: "name", Column: "u.name"}, {Field: "age", Column: "age", Desc: true}},
+				Filters: []Filter{
+					{Field: "active", Column: "active", Operator: OpNull, Values: []interface{}{false}},
+					{Field: "age", Column: "age", Operator: OpGte, Values: []interface{}{int64(18)}},
+					{Field: "age", Column: "age", Operator: OpIn, Values: []interface{}{int64(18), int64(21)}},
+					{Field: "created_at", Column: "created_at", Operator: OpLt, Values: []interface{}{day}},
+					{Field: "name", Column: "u.name", Operator: OpEq, Values: []interface{}{"bob"}},
+				},
+			},
+		},
+		{
+			name:  "like escapes the wildcards",
+			query: "sortBy=id&name[like]=50%25_off",
+			want: Spec{
+				Sort:    []Sort{{Field: "id", Column: "id"}},
+				Filters: []Filter{{Field: "name", Column: "u.name", Operator: OpLike, Values: []interface{}{"%50!%!_off%"}}},
+			},
+		},
+		{
+			name:        "every invalid field is reported",
+			query:       "sortBy=score,-active&age=old&score[gt]=1&active[like]=x&unknown[eq]=1",
+			wantInvalid: []string{SortParam, SortParam, "active[like]", "age", "score[gt]", "unknown[eq]"},
+		},
+		{
+			name:        "too many sort fields",
+			query:       "sortBy=name,age,id",
+			wantInvalid: []string{SortParam},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("url.ParseQuery() error = %v", err)
+			}
+
+			got, err := testSchema.Parse(query)
+			if len(tt.wantInvalid) > 0 {
+				fe, ok := fielderror.As(err)
+				if !ok {
+					t.Fatalf("Parse() error = %v, want a field error", err)
+				}
+
+				names := make([]string, len(fe.Fields))
+				for i, f := range fe.Fields {
+					names[i] = f.Field
+				}
+				if !reflect.DeepEqual(names, tt.wantInvalid) {
+					t.Errorf("Parse() invalid fields = %v, want %v", names, tt.wantInvalid)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchema_Parse_invalidSchema(t *testing.T) {
+	s := Schema{Fields: []Field{{Name: "name", Column: "name; DROP TABLE users", Type: TypeString, Filterable: true}}}
+
+	_, err := s.Parse(url.Values{"name": {"bob"}})
+	if _, ok := fielderror.As(err); ok {
+		t.Fatalf("Parse() error = %v, a schema mistake must not be reported as the client's", err)
+	}
+	if code := errors.GetCode(err); code != codes.CodeInternalServerError {
+		t.Errorf("Parse() error code = %v, want %v", code, codes.CodeInternalServerError)
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  Schema
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			schema: testSchema,
+		},
+		{
+			name:    "field without a name",
+			schema:  Schema{Fields: []Field{{Type: TypeString}}},
+			wantErr: true,
+		},
+		{
+			name:    "field declared twice",
+			schema:  Schema{Fields: []Field{{Name: "id", Type: TypeInt}, {Name: "id", Type: TypeString}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid column",
+			schema:  Schema{Fields: []Field{{Name: "name", Column: "lower(name)", Type: TypeString}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			schema:  Schema{Fields: []Field{{Name: "tags", Type: "array"}}},
+			wantErr: true,
+		},
+		{
+			name:    "operator not fitting the type",
+			schema:  Schema{Fields: []Field{{Name: "active", Type: TypeBool, Operators: []Operator{OpGt}}}},
+			wantErr: true,
+		},
+		{
+			name:    "default sort on a field that is not sortable",
+			schema:  Schema{Fields: []Field{{Name: "name", Type: TypeString}}, DefaultSort: []string{"name"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.schema.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMustSchema(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MustSchema() did not panic on an invalid schema")
+		}
+	}()
+
+	MustSchema(Schema{Fields: []Field{{Name: "name", Column: "1name", Type: TypeString}}})
+}
+
+func TestSpec_Render(t *testing.T) {
+	tests := []struct {
+		name string
+		spec Spec
+		want Clause
+	}{
+		{
+			name: "empty",
+			spec: Spec{},
+			want: Clause{},
+		},
+		{
+			name: "filters and sort",
+			spec: Spec{
+				Sort: []Sort{{Column: "u.name"}, {Column: "id", Desc: true}},
+				Filters: []Filter{
+					{Column: "age", Operator: OpGte, Values: []interface{}{int64(18)}},
+					{Column: "status", Operator: OpNin, Values: []interface{}{"a", "b"}},
+					{Column: "deleted_at", Operator: OpNull, Values: []interface{}{true}},
+					{Column: "u.name", Operator: OpLike, Values: []interface{}{"%bob%"}},
+				},
+			},
+			want: Clause{
+				Where:   "age >= ? AND status NOT IN (?, ?) AND deleted_at IS NULL AND u.name LIKE ? ESCAPE '!'",
+				OrderBy: "u.name ASC, id DESC",
+				Args:    []interface{}{int64(18), "a", "b", "%bob%"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spec.Render(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Render() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
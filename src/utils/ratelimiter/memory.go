@@ -0,0 +1,85 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	period time.Duration
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func initMemory(cfg Config) Interface {
+	m := &memoryStore{
+		buckets: map[string]*bucket{},
+		stop:    make(chan struct{}),
+	}
+
+	go m.cleanup(cfg.CleanupInterval)
+
+	return m
+}
+
+func (m *memoryStore) Take(ctx context.Context, key string, rate Rate) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rate.Limit)}
+		m.buckets[key] = b
+	}
+
+	tokens, res := take(b.tokens, b.last, now, rate)
+	b.tokens, b.last, b.period = tokens, now, rate.Period
+
+	return res, nil
+}
+
+// Cleanup drops buckets idle long enough to be full again, they are recreated full on the next request
+func (m *memoryStore) Cleanup(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int64
+	now := time.Now()
+	for key, b := range m.buckets {
+		if now.Sub(b.last) > b.period {
+			delete(m.buckets, key)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// cleanup runs Cleanup every interval until Stop, buckets in memory go away with the replica so
+// they are swept here rather than by the scheduler
+func (m *memoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.Cleanup(context.Background())
+		}
+	}
+}
+
+func (m *memoryStore) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
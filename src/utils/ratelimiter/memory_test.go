@@ -0,0 +1,80 @@
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_memoryStore_Take(t *testing.T) {
+	m := &memoryStore{buckets: map[string]*bucket{}}
+	rate := Rate{Limit: 2, Period: time.Hour}
+
+	tests := []struct {
+		name          string
+		key           string
+		wantAllowed   bool
+		wantRemaining int64
+	}{
+		{name: "first", key: "a", wantAllowed: true, wantRemaining: 1},
+		{name: "second", key: "a", wantAllowed: true, wantRemaining: 0},
+		{name: "over the limit", key: "a", wantAllowed: false, wantRemaining: 0},
+		{name: "other key has its own bucket", key: "b", wantAllowed: true, wantRemaining: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Take(context.Background(), tt.key, rate)
+			if err != nil {
+				t.Fatalf("Take() error = %v", err)
+			}
+			if got.Allowed != tt.wantAllowed || got.Remaining != tt.wantRemaining {
+				t.Errorf("Take() = %+v, want allowed %v remaining %d", got, tt.wantAllowed, tt.wantRemaining)
+			}
+			if !got.Allowed && got.RetryAfter <= 0 {
+				t.Errorf("Take() RetryAfter = %v, want a wait when denied", got.RetryAfter)
+			}
+		})
+	}
+}
+
+func Test_memoryStore_Cleanup(t *testing.T) {
+	now := time.Now()
+	m := &memoryStore{buckets: map[string]*bucket{
+		"idle":   {tokens: 0, last: now.Add(-2 * time.Minute), period: time.Minute},
+		"active": {tokens: 0, last: now, period: time.Minute},
+	}}
+
+	removed, err := m.Cleanup(context.Background())
+	if err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Cleanup() removed = %d, want 1", removed)
+	}
+	if _, ok := m.buckets["idle"]; ok {
+		t.Errorf("Cleanup() kept the idle bucket")
+	}
+	if _, ok := m.buckets["active"]; !ok {
+		t.Errorf("Cleanup() removed the active bucket")
+	}
+}
+
+func Test_memoryStore_Stop(t *testing.T) {
+	m := &memoryStore{buckets: map[string]*bucket{}, stop: make(chan struct{})}
+
+	done := make(chan struct{})
+	go func() {
+		m.cleanup(time.Millisecond)
+		close(done)
+	}()
+
+	// stopping twice, e.g. by a test and the shutdown, is fine
+	m.Stop()
+	m.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("cleanup still runs after Stop()")
+	}
+}
@@ -0,0 +1,98 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/downsized-devs/sdk-go/sql"
+)
+
+const (
+	StoreMemory string = "memory"
+	StoreSQL    string = "sql"
+
+	defaultCleanupInterval time.Duration = time.Minute
+	defaultTable           string        = "rate_limit_bucket"
+)
+
+// Interface takes tokens out of the bucket identified by key, the backing store decides
+// whether buckets are local to this replica or shared between replicas
+type Interface interface {
+	Take(ctx context.Context, key string, rate Rate) (Result, error)
+	// Cleanup removes every bucket idle long enough to be full again and returns how many were removed
+	Cleanup(ctx context.Context) (int64, error)
+	// Stop ends the background cleanup of the memory store, it is a no-op for the sql store
+	Stop()
+}
+
+// Config picks the memory or sql Store. CleanupInterval is how often the memory store drops idle
+// buckets, the sql buckets are removed by the RateLimitCleanup scheduler task
+type Config struct {
+	Store           string
+	Table           string
+	CleanupInterval time.Duration
+}
+
+// Rate allows Limit requests in a burst, refilled evenly over Period
+type Rate struct {
+	Limit  int64
+	Period time.Duration
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+func Init(cfg Config, log logger.Interface, db sql.Interface) Interface {
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = defaultCleanupInterval
+	}
+
+	if cfg.Table == "" {
+		cfg.Table = defaultTable
+	}
+
+	switch cfg.Store {
+	case StoreSQL:
+		return initSQL(cfg, db)
+	case StoreMemory, "":
+		return initMemory(cfg)
+	default:
+		log.Fatal(context.Background(), fmt.Sprintf("Unknown rate limiter store %s", cfg.Store))
+		return nil
+	}
+}
+
+// take refills the bucket for the time elapsed since its last update and tries to consume one token
+func take(tokens float64, last, now time.Time, rate Rate) (float64, Result) {
+	limit := float64(rate.Limit)
+	refillPerSecond := limit / rate.Period.Seconds()
+
+	if !last.IsZero() {
+		tokens = math.Min(limit, tokens+now.Sub(last).Seconds()*refillPerSecond)
+	}
+
+	res := Result{Limit: rate.Limit}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - tokens) / refillPerSecond)
+	}
+
+	res.Remaining = int64(math.Floor(tokens))
+	res.Reset = secondsToDuration((limit - tokens) / refillPerSecond)
+
+	return tokens, res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,123 @@
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	mock_sql "github.com/downsized-devs/sdk-go/tests/mock/sql"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	dbMock := mock_sql.NewMockInterface(ctrl)
+
+	tests := []struct {
+		name      string
+		cfg       Config
+		mockFunc  func()
+		wantStore interface{}
+	}{
+		{
+			name:      "memory by default",
+			cfg:       Config{},
+			mockFunc:  func() {},
+			wantStore: &memoryStore{},
+		},
+		{
+			name:      "sql",
+			cfg:       Config{Store: StoreSQL},
+			mockFunc:  func() {},
+			wantStore: &sqlStore{},
+		},
+		{
+			name: "unknown store",
+			cfg:  Config{Store: "redis"},
+			mockFunc: func() {
+				logMock.EXPECT().Fatal(gomock.Any(), gomock.Any())
+			},
+			wantStore: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFunc()
+
+			got := Init(tt.cfg, logMock, dbMock)
+			switch tt.wantStore.(type) {
+			case *memoryStore:
+				if _, ok := got.(*memoryStore); !ok {
+					t.Errorf("Init() = %T, want *memoryStore", got)
+				}
+			case *sqlStore:
+				s, ok := got.(*sqlStore)
+				if !ok {
+					t.Fatalf("Init() = %T, want *sqlStore", got)
+				}
+				if s.table != defaultTable {
+					t.Errorf("Init() table = %q, want %q", s.table, defaultTable)
+				}
+			default:
+				if got != nil {
+					t.Errorf("Init() = %T, want nil", got)
+				}
+			}
+		})
+	}
+}
+
+func Test_take(t *testing.T) {
+	now := time.Now()
+	rate := Rate{Limit: 10, Period: 10 * time.Second}
+
+	type args struct {
+		tokens float64
+		last   time.Time
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantTokens float64
+		want       Result
+	}{
+		{
+			name:       "new bucket",
+			args:       args{tokens: 10},
+			wantTokens: 9,
+			want:       Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second},
+		},
+		{
+			name:       "refilled since the last take",
+			args:       args{tokens: 2, last: now.Add(-3 * time.Second)},
+			wantTokens: 4,
+			want:       Result{Allowed: true, Limit: 10, Remaining: 4, Reset: 6 * time.Second},
+		},
+		{
+			name:       "refill capped at the limit",
+			args:       args{tokens: 5, last: now.Add(-time.Hour)},
+			wantTokens: 9,
+			want:       Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second},
+		},
+		{
+			name:       "empty",
+			args:       args{tokens: 0.5, last: now},
+			wantTokens: 0.5,
+			want:       Result{Allowed: false, Limit: 10, Remaining: 0, Reset: 9500 * time.Millisecond, RetryAfter: 500 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTokens, got := take(tt.args.tokens, tt.args.last, now, rate)
+			if gotTokens != tt.wantTokens {
+				t.Errorf("take() tokens = %v, want %v", gotTokens, tt.wantTokens)
+			}
+			if got != tt.want {
+				t.Errorf("take() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/sql"
+)
+
+// The sql store expects a table shared by every replica:
+//
+//	CREATE TABLE rate_limit_bucket (
+//		bucket_key VARCHAR(255) PRIMARY KEY,
+//		tokens     DOUBLE PRECISION NOT NULL,
+//		updated_at BIGINT NOT NULL, -- unix milliseconds
+//		expires_at BIGINT NOT NULL -- unix milliseconds, when the bucket is full again
+//	);
+const (
+	readBucketForUpdate = `SELECT tokens, updated_at FROM %s WHERE bucket_key = ? FOR UPDATE`
+	insertBucket        = `INSERT INTO %s (bucket_key, tokens, updated_at, expires_at) VALUES (?, ?, ?, ?)`
+	updateBucket        = `UPDATE %s SET tokens = ?, updated_at = ?, expires_at = ? WHERE bucket_key = ?`
+	deleteIdle          = `DELETE FROM %s WHERE expires_at <= ?`
+)
+
+type sqlBucket struct {
+	Tokens    float64 `db:"tokens"`
+	UpdatedAt int64   `db:"updated_at"`
+}
+
+type sqlStore struct {
+	db    sql.Interface
+	table string
+}
+
+func initSQL(cfg Config, db sql.Interface) Interface {
+	return &sqlStore{
+		db:    db,
+		table: cfg.Table,
+	}
+}
+
+func (s *sqlStore) Take(ctx context.Context, key string, rate Rate) (Result, error) {
+	res, inserted, err := s.take(ctx, key, rate)
+	if err != nil && inserted {
+		// two first hits on a key both find no bucket and race to insert it, the one
+		// losing takes again and this time locks the bucket the other one inserted
+		res, _, err = s.take(ctx, key, rate)
+	}
+
+	return res, err
+}
+
+// take updates the bucket in a transaction, inserted tells the bucket did not exist yet
+func (s *sqlStore) take(ctx context.Context, key string, rate Rate) (Result, bool, error) {
+	tx, err := s.db.Leader().BeginTx(ctx, "txRateLimitTake", sql.TxOptions{})
+	if err != nil {
+		return Result{}, false, errors.NewWithCode(codes.CodeSQLTxBegin, "%s", err.Error())
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	b := sqlBucket{}
+	found := true
+	err = tx.Get("rRateLimitBucket", tx.Rebind(fmt.Sprintf(readBucketForUpdate, s.table)), &b, key)
+	if errors.Is(err, sql.ErrNotFound) {
+		found = false
+		b.Tokens = float64(rate.Limit)
+	} else if err != nil {
+		return Result{}, false, errors.NewWithCode(codes.CodeSQLRead, "%s", err.Error())
+	}
+
+	last := time.Time{}
+	if found {
+		last = time.UnixMilli(b.UpdatedAt)
+	}
+
+	tokens, res := take(b.Tokens, last, now, rate)
+	expiresAt := now.Add(res.Reset).UnixMilli()
+
+	if found {
+		_, err = tx.Exec("uRateLimitBucket", tx.Rebind(fmt.Sprintf(updateBucket, s.table)), tokens, now.UnixMilli(), expiresAt, key)
+	} else {
+		_, err = tx.Exec("iRateLimitBucket", tx.Rebind(fmt.Sprintf(insertBucket, s.table)), key, tokens, now.UnixMilli(), expiresAt)
+	}
+	if err != nil {
+		return Result{}, !found, errors.NewWithCode(codes.CodeSQLTxExec, "%s", err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Result{}, !found, errors.NewWithCode(codes.CodeSQLTxCommit, "%s", err.Error())
+	}
+
+	return res, !found, nil
+}
+
+func (s *sqlStore) Stop() {}
+
+func (s *sqlStore) Cleanup(ctx context.Context) (int64, error) {
+	res, err := s.db.Leader().Exec(ctx, "dRateLimitBucketIdle", s.db.Leader().Rebind(fmt.Sprintf(deleteIdle, s.table)), time.Now().UnixMilli())
+	if err != nil {
+		return 0, errors.NewWithCode(codes.CodeSQLTxExec, "%s", err.Error())
+	}
+
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.NewWithCode(codes.CodeSQL, "%s", err.Error())
+	}
+
+	return removed, nil
+}
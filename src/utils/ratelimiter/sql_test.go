@@ -0,0 +1,166 @@
+package ratelimiter
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/sql"
+	mock_sql "github.com/downsized-devs/sdk-go/tests/mock/sql"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_sqlStore_Take(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dbMock := mock_sql.NewMockInterface(ctrl)
+	leaderMock := mock_sql.NewMockCommand(ctrl)
+	txMock := mock_sql.NewMockCommandTx(ctrl)
+
+	type mockFields struct {
+		db     *mock_sql.MockInterface
+		leader *mock_sql.MockCommand
+		tx     *mock_sql.MockCommandTx
+	}
+
+	mocks := mockFields{
+		db:     dbMock,
+		leader: leaderMock,
+		tx:     txMock,
+	}
+
+	rate := Rate{Limit: 10, Period: time.Minute}
+	recent := time.Now().Add(-time.Second).UnixMilli()
+
+	tests := []struct {
+		name          string
+		mockFunc      func(m mockFields)
+		wantRemaining int64
+		wantErr       bool
+	}{
+		{
+			name: "existing bucket is updated",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txRateLimitTake", gomock.Any()).Return(m.tx, nil)
+				m.tx.EXPECT().Get("rRateLimitBucket", gomock.Any(), gomock.Any(), "ip:1").SetArg(2, sqlBucket{Tokens: 5, UpdatedAt: recent})
+				m.tx.EXPECT().Exec("uRateLimitBucket", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "ip:1").Return(driver.RowsAffected(1), nil)
+				m.tx.EXPECT().Commit().Return(nil)
+			},
+			wantRemaining: 4,
+		},
+		{
+			name: "new bucket is inserted full",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txRateLimitTake", gomock.Any()).Return(m.tx, nil)
+				m.tx.EXPECT().Get("rRateLimitBucket", gomock.Any(), gomock.Any(), "ip:1").Return(sql.ErrNotFound)
+				m.tx.EXPECT().Exec("iRateLimitBucket", gomock.Any(), "ip:1", float64(9), gomock.Any(), gomock.Any()).Return(driver.RowsAffected(1), nil)
+				m.tx.EXPECT().Commit().Return(nil)
+			},
+			wantRemaining: 9,
+		},
+		{
+			name: "insert lost to a concurrent first hit takes again",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txRateLimitTake", gomock.Any()).Return(m.tx, nil).Times(2)
+				gomock.InOrder(
+					m.tx.EXPECT().Get("rRateLimitBucket", gomock.Any(), gomock.Any(), "ip:1").Return(sql.ErrNotFound),
+					m.tx.EXPECT().Get("rRateLimitBucket", gomock.Any(), gomock.Any(), "ip:1").SetArg(2, sqlBucket{Tokens: 9, UpdatedAt: time.Now().UnixMilli()}),
+				)
+				m.tx.EXPECT().Exec("iRateLimitBucket", gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("duplicate key"))
+				m.tx.EXPECT().Exec("uRateLimitBucket", gomock.Any(), gomock.Any()).Return(driver.RowsAffected(1), nil)
+				m.tx.EXPECT().Commit().Return(nil)
+			},
+			wantRemaining: 8,
+		},
+		{
+			name: "update error is not retried",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txRateLimitTake", gomock.Any()).Return(m.tx, nil)
+				m.tx.EXPECT().Get("rRateLimitBucket", gomock.Any(), gomock.Any(), "ip:1").SetArg(2, sqlBucket{Tokens: 5, UpdatedAt: recent})
+				m.tx.EXPECT().Exec("uRateLimitBucket", gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("lock wait timeout"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "read error",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txRateLimitTake", gomock.Any()).Return(m.tx, nil)
+				m.tx.EXPECT().Get("rRateLimitBucket", gomock.Any(), gomock.Any(), "ip:1").Return(fmt.Errorf("connection refused"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "begin error",
+			mockFunc: func(m mockFields) {
+				m.leader.EXPECT().BeginTx(gomock.Any(), "txRateLimitTake", gomock.Any()).Return(nil, fmt.Errorf("connection refused"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMock.EXPECT().Leader().Return(leaderMock).AnyTimes()
+			txMock.EXPECT().Rebind(gomock.Any()).DoAndReturn(func(q string) string { return q }).AnyTimes()
+			txMock.EXPECT().Rollback().AnyTimes()
+			tt.mockFunc(mocks)
+
+			s := initSQL(Config{Table: defaultTable}, dbMock)
+			got, err := s.Take(context.Background(), "ip:1", rate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Take() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Remaining != tt.wantRemaining {
+				t.Errorf("Take() remaining = %d, want %d", got.Remaining, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func Test_sqlStore_Cleanup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dbMock := mock_sql.NewMockInterface(ctrl)
+	leaderMock := mock_sql.NewMockCommand(ctrl)
+
+	tests := []struct {
+		name     string
+		mockFunc func()
+		want     int64
+		wantErr  bool
+	}{
+		{
+			name: "removes idle buckets",
+			mockFunc: func() {
+				leaderMock.EXPECT().Exec(gomock.Any(), "dRateLimitBucketIdle", gomock.Any(), gomock.Any()).Return(driver.RowsAffected(3), nil)
+			},
+			want: 3,
+		},
+		{
+			name: "exec error",
+			mockFunc: func() {
+				leaderMock.EXPECT().Exec(gomock.Any(), "dRateLimitBucketIdle", gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("connection refused"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMock.EXPECT().Leader().Return(leaderMock).AnyTimes()
+			leaderMock.EXPECT().Rebind(gomock.Any()).DoAndReturn(func(q string) string { return q }).AnyTimes()
+			tt.mockFunc()
+
+			s := initSQL(Config{Table: defaultTable}, dbMock)
+			got, err := s.Cleanup(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Cleanup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Cleanup() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
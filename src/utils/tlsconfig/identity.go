@@ -0,0 +1,49 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+)
+
+type contextKey string
+
+const clientIdentityKey contextKey = "tlsClientIdentity"
+
+// ClientIdentity describes the verified certificate a client presented over mTLS
+type ClientIdentity struct {
+	CommonName   string   `json:"commonName"`
+	Organization []string `json:"organization,omitempty"`
+	DNSNames     []string `json:"dnsNames,omitempty"`
+	URIs         []string `json:"uris,omitempty"`
+	SerialNumber string   `json:"serialNumber"`
+	Fingerprint  string   `json:"fingerprint"`
+}
+
+func NewClientIdentity(cert *x509.Certificate) ClientIdentity {
+	sum := sha256.Sum256(cert.Raw)
+	identity := ClientIdentity{
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+		DNSNames:     cert.DNSNames,
+		SerialNumber: cert.SerialNumber.String(),
+		Fingerprint:  hex.EncodeToString(sum[:]),
+	}
+
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+
+	return identity
+}
+
+func SetClientIdentity(ctx context.Context, identity ClientIdentity) context.Context {
+	return context.WithValue(ctx, clientIdentityKey, identity)
+}
+
+// GetClientIdentity returns the identity of the mTLS client, ok is false when none was verified
+func GetClientIdentity(ctx context.Context) (ClientIdentity, bool) {
+	identity, ok := ctx.Value(clientIdentityKey).(ClientIdentity)
+	return identity, ok
+}
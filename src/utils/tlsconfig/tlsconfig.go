@@ -0,0 +1,241 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+)
+
+const (
+	ClientAuthNone    string = "none"
+	ClientAuthRequest string = "request"
+	ClientAuthRequire string = "require"
+
+	defaultReloadInterval time.Duration = 30 * time.Second
+
+	nextProtoHTTP2 string = "h2"
+	nextProtoHTTP1 string = "http/1.1"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+type Interface interface {
+	IsEnabled() bool
+	// TLSConfig always serves the latest certificate and client CA bundle loaded from disk
+	TLSConfig() *tls.Config
+}
+
+// Config MinVersion is 1.2 or 1.3. CipherSuites take the Go names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+// and only apply up to TLS 1.2. Files are checked for changes every ReloadInterval
+type Config struct {
+	Enabled        bool
+	CertFile       string
+	KeyFile        string
+	MinVersion     string
+	CipherSuites   []string
+	ReloadInterval time.Duration
+	ClientAuth     ClientAuthConfig
+}
+
+// ClientAuthConfig Mode is none, request (verify a certificate when one is sent)
+// or require (reject clients without a certificate signed by CAFile)
+type ClientAuthConfig struct {
+	Mode   string
+	CAFile string
+}
+
+type fileStamp struct {
+	cert, key, ca time.Time
+}
+
+type tlsConfig struct {
+	conf   Config
+	log    logger.Interface
+	base   *tls.Config
+	cert   atomic.Pointer[tls.Certificate]
+	ca     atomic.Pointer[x509.CertPool]
+	loaded fileStamp
+}
+
+func Init(cfg Config, log logger.Interface) Interface {
+	t := &tlsConfig{conf: cfg, log: log}
+	if !cfg.Enabled {
+		return t
+	}
+
+	if cfg.ReloadInterval <= 0 {
+		t.conf.ReloadInterval = defaultReloadInterval
+	}
+
+	ctx := context.Background()
+	base, err := t.buildBase()
+	if err != nil {
+		log.Fatal(ctx, fmt.Sprintf("Invalid TLS config: %s", err.Error()))
+	}
+	t.base = base
+
+	stamp, err := t.stat()
+	if err != nil {
+		log.Fatal(ctx, fmt.Sprintf("Reading TLS files error: %s", err.Error()))
+	}
+
+	if err := t.load(stamp); err != nil {
+		log.Fatal(ctx, fmt.Sprintf("Loading TLS certificates error: %s", err.Error()))
+	}
+
+	go t.watch()
+
+	return t
+}
+
+func (t *tlsConfig) IsEnabled() bool {
+	return t.conf.Enabled
+}
+
+func (t *tlsConfig) TLSConfig() *tls.Config {
+	return t.base
+}
+
+func (t *tlsConfig) buildBase() (*tls.Config, error) {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return t.cert.Load(), nil
+		},
+		// set here rather than left to the http server, which only adds them to the config it is given
+		// and not to the per client copies below
+		NextProtos: []string{nextProtoHTTP2, nextProtoHTTP1},
+	}
+
+	if t.conf.MinVersion != "" {
+		v, ok := tlsVersions[t.conf.MinVersion]
+		if !ok {
+			return nil, errors.NewWithCode(codes.CodeInvalidValue, "unsupported MinVersion %q, use 1.2 or 1.3", t.conf.MinVersion)
+		}
+		base.MinVersion = v
+	}
+
+	if len(t.conf.CipherSuites) > 0 {
+		suites := map[string]uint16{}
+		for _, s := range tls.CipherSuites() {
+			suites[s.Name] = s.ID
+		}
+
+		for _, name := range t.conf.CipherSuites {
+			id, ok := suites[name]
+			if !ok {
+				return nil, errors.NewWithCode(codes.CodeInvalidValue, "unknown or insecure cipher suite %q", name)
+			}
+			base.CipherSuites = append(base.CipherSuites, id)
+		}
+	}
+
+	switch t.conf.ClientAuth.Mode {
+	case ClientAuthNone, "":
+		return base, nil
+	case ClientAuthRequest:
+		base.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, errors.NewWithCode(codes.CodeInvalidValue, "unknown ClientAuth.Mode %q", t.conf.ClientAuth.Mode)
+	}
+
+	if t.conf.ClientAuth.CAFile == "" {
+		return nil, errors.NewWithCode(codes.CodeInvalidValue, "ClientAuth.Mode %s needs a CAFile", t.conf.ClientAuth.Mode)
+	}
+
+	// the client CA pool can not be swapped through a callback like the certificate,
+	// so every handshake gets a copy of the config carrying the latest pool
+	server := base.Clone()
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := server.Clone()
+		c.ClientCAs = t.ca.Load()
+		return c, nil
+	}
+
+	return base, nil
+}
+
+func (t *tlsConfig) stat() (fileStamp, error) {
+	stamp := fileStamp{}
+	files := map[string]*time.Time{
+		t.conf.CertFile: &stamp.cert,
+		t.conf.KeyFile:  &stamp.key,
+	}
+	if t.conf.ClientAuth.CAFile != "" {
+		files[t.conf.ClientAuth.CAFile] = &stamp.ca
+	}
+
+	for name, modTime := range files {
+		info, err := os.Stat(name)
+		if err != nil {
+			return stamp, errors.NewWithCode(codes.CodeFilePathOpenFailed, "%s", err.Error())
+		}
+		*modTime = info.ModTime()
+	}
+
+	return stamp, nil
+}
+
+// load swaps in the files that changed since the last load, keeping the previous ones on error
+func (t *tlsConfig) load(stamp fileStamp) error {
+	if !stamp.cert.Equal(t.loaded.cert) || !stamp.key.Equal(t.loaded.key) {
+		cert, err := tls.LoadX509KeyPair(t.conf.CertFile, t.conf.KeyFile)
+		if err != nil {
+			return errors.NewWithCode(codes.CodeInvalidValue, "%s", err.Error())
+		}
+		t.cert.Store(&cert)
+	}
+
+	if t.conf.ClientAuth.CAFile != "" && !stamp.ca.Equal(t.loaded.ca) {
+		raw, err := os.ReadFile(t.conf.ClientAuth.CAFile)
+		if err != nil {
+			return errors.NewWithCode(codes.CodeFilePathOpenFailed, "%s", err.Error())
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(raw) {
+			return errors.NewWithCode(codes.CodeInvalidValue, "no certificate found in %s", t.conf.ClientAuth.CAFile)
+		}
+		t.ca.Store(pool)
+	}
+
+	t.loaded = stamp
+	return nil
+}
+
+func (t *tlsConfig) watch() {
+	ticker := time.NewTicker(t.conf.ReloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		stamp, err := t.stat()
+		if err != nil {
+			t.log.Error(ctx, fmt.Sprintf("Reading TLS files error, keeping the loaded certificates: %s", err.Error()))
+			continue
+		}
+
+		if stamp == t.loaded {
+			continue
+		}
+
+		if err := t.load(stamp); err != nil {
+			t.log.Error(ctx, fmt.Sprintf("Reloading TLS certificates error, keeping the loaded certificates: %s", err.Error()))
+			continue
+		}
+		t.log.Info(ctx, "Reloaded TLS certificates")
+	}
+}
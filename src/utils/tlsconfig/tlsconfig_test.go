@@ -0,0 +1,310 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"go.uber.org/mock/gomock"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+
+	cert, _ := x509.ParseCertificate(raw)
+	return testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: raw})}
+}
+
+// issue signs a certificate for the localhost server or a client named commonName
+func (ca testCA) issue(t *testing.T, serial int64, commonName string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"downsized"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("x509.MarshalECPrivateKey() error = %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: raw}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+// writeFile writes content to name in dir and moves its modification time forward by age,
+// so consecutive writes within the clock resolution are still seen as changes
+func writeFile(t *testing.T, dir, name string, content []byte, age time.Duration) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	modTime := time.Now().Add(age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("os.Chtimes() error = %v", err)
+	}
+
+	return path
+}
+
+func Test_tlsConfig_buildBase(t *testing.T) {
+	tests := []struct {
+		name           string
+		conf           Config
+		wantMinVersion uint16
+		wantErr        bool
+	}{
+		{
+			name:           "defaults",
+			conf:           Config{},
+			wantMinVersion: tls.VersionTLS12,
+		},
+		{
+			name:           "tls 1.3",
+			conf:           Config{MinVersion: "1.3", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+			wantMinVersion: tls.VersionTLS13,
+		},
+		{
+			name:    "unsupported version",
+			conf:    Config{MinVersion: "1.0"},
+			wantErr: true,
+		},
+		{
+			name:    "insecure cipher suite",
+			conf:    Config{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown client auth mode",
+			conf:    Config{ClientAuth: ClientAuthConfig{Mode: "optional", CAFile: "ca.pem"}},
+			wantErr: true,
+		},
+		{
+			name:    "client auth without a ca",
+			conf:    Config{ClientAuth: ClientAuthConfig{Mode: ClientAuthRequire}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &tlsConfig{conf: tt.conf}
+			got, err := tc.buildBase()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildBase() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.MinVersion != tt.wantMinVersion {
+				t.Errorf("buildBase() MinVersion = %x, want %x", got.MinVersion, tt.wantMinVersion)
+			}
+		})
+	}
+}
+
+func Test_tlsConfig_load(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+
+	certA, keyA := ca.issue(t, 2, "server a", x509.ExtKeyUsageServerAuth)
+	certB, keyB := ca.issue(t, 3, "server b", x509.ExtKeyUsageServerAuth)
+
+	tc := &tlsConfig{conf: Config{
+		CertFile: writeFile(t, dir, "cert.pem", certA, -2*time.Minute),
+		KeyFile:  writeFile(t, dir, "key.pem", keyA, -2*time.Minute),
+	}}
+
+	tests := []struct {
+		name     string
+		cert     []byte
+		key      []byte
+		age      time.Duration
+		wantErr  bool
+		wantCert string
+	}{
+		{
+			name:     "initial load",
+			wantCert: "server a",
+		},
+		{
+			name:     "changed files are reloaded",
+			cert:     certB,
+			key:      keyB,
+			age:      -time.Minute,
+			wantCert: "server b",
+		},
+		{
+			name:     "broken files keep the loaded certificate",
+			cert:     []byte("not a certificate"),
+			key:      keyB,
+			age:      0,
+			wantErr:  true,
+			wantCert: "server b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.cert != nil {
+				writeFile(t, dir, "cert.pem", tt.cert, tt.age)
+				writeFile(t, dir, "key.pem", tt.key, tt.age)
+			}
+
+			stamp, err := tc.stat()
+			if err != nil {
+				t.Fatalf("stat() error = %v", err)
+			}
+
+			if err := tc.load(stamp); (err != nil) != tt.wantErr {
+				t.Fatalf("load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			leaf, err := x509.ParseCertificate(tc.cert.Load().Certificate[0])
+			if err != nil {
+				t.Fatalf("x509.ParseCertificate() error = %v", err)
+			}
+			if leaf.Subject.CommonName != tt.wantCert {
+				t.Errorf("load() serves %q, want %q", leaf.Subject.CommonName, tt.wantCert)
+			}
+		})
+	}
+}
+
+func TestInit_clientAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+
+	ca := newTestCA(t)
+	other := newTestCA(t)
+	dir := t.TempDir()
+
+	serverCert, serverKey := ca.issue(t, 2, "server", x509.ExtKeyUsageServerAuth)
+	tc := Init(Config{
+		Enabled:  true,
+		CertFile: writeFile(t, dir, "cert.pem", serverCert, 0),
+		KeyFile:  writeFile(t, dir, "key.pem", serverKey, 0),
+		ClientAuth: ClientAuthConfig{
+			Mode:   ClientAuthRequire,
+			CAFile: writeFile(t, dir, "ca.pem", ca.pem, 0),
+		},
+	}, logMock)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(NewClientIdentity(r.TLS.VerifiedChains[0][0]).CommonName))
+	}))
+	srv.TLS = tc.TLSConfig()
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+
+	trustedCert, trustedKey := ca.issue(t, 3, "billing-service", x509.ExtKeyUsageClientAuth)
+	untrustedCert, untrustedKey := other.issue(t, 4, "unknown-service", x509.ExtKeyUsageClientAuth)
+
+	tests := []struct {
+		name         string
+		cert         []byte
+		key          []byte
+		wantErr      bool
+		wantIdentity string
+	}{
+		{
+			name:         "client signed by the ca",
+			cert:         trustedCert,
+			key:          trustedKey,
+			wantIdentity: "billing-service",
+		},
+		{
+			name:    "client signed by another ca",
+			cert:    untrustedCert,
+			key:     untrustedKey,
+			wantErr: true,
+		},
+		{
+			name:    "client without a certificate",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientConf := &tls.Config{RootCAs: roots}
+			if tt.cert != nil {
+				pair, err := tls.X509KeyPair(tt.cert, tt.key)
+				if err != nil {
+					t.Fatalf("tls.X509KeyPair() error = %v", err)
+				}
+				clientConf.Certificates = []tls.Certificate{pair}
+			}
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConf}}
+			resp, err := client.Get(srv.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+
+			body := make([]byte, 64)
+			n, _ := resp.Body.Read(body)
+			if got := string(body[:n]); got != tt.wantIdentity {
+				t.Errorf("client identity = %q, want %q", got, tt.wantIdentity)
+			}
+		})
+	}
+}
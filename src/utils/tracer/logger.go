@@ -0,0 +1,67 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+)
+
+// tracedLogger adds the trace id of the context to every message written through the wrapped
+// logger, so a log line can be joined with its trace
+type tracedLogger struct {
+	logger.Interface
+}
+
+// WrapLogger returns log writing a trace_id next to the message of every traced context
+func WrapLogger(log logger.Interface) logger.Interface {
+	return &tracedLogger{Interface: log}
+}
+
+func (l *tracedLogger) Trace(ctx context.Context, obj any) {
+	l.Interface.Trace(ctx, withTraceID(ctx, obj))
+}
+
+func (l *tracedLogger) Debug(ctx context.Context, obj any) {
+	l.Interface.Debug(ctx, withTraceID(ctx, obj))
+}
+
+func (l *tracedLogger) Info(ctx context.Context, obj any) {
+	l.Interface.Info(ctx, withTraceID(ctx, obj))
+}
+
+func (l *tracedLogger) Warn(ctx context.Context, obj any) {
+	l.Interface.Warn(ctx, withTraceID(ctx, obj))
+}
+
+func (l *tracedLogger) Error(ctx context.Context, obj any) {
+	l.Interface.Error(ctx, withTraceID(ctx, obj))
+}
+
+func (l *tracedLogger) Fatal(ctx context.Context, obj any) {
+	l.Interface.Fatal(ctx, withTraceID(ctx, obj))
+}
+
+// withTraceID leaves obj as is outside a trace. Errors keep the origin the logger would print for them
+func withTraceID(ctx context.Context, obj any) any {
+	traceID := TraceID(ctx)
+	if traceID == "" {
+		return obj
+	}
+
+	var msg string
+	switch o := obj.(type) {
+	case error:
+		msg = o.Error()
+		if file, line, errMsg, err := errors.GetCaller(o); err == nil {
+			msg = fmt.Sprintf("%s:%#v --- %s", file, line, errMsg)
+		}
+	case string:
+		msg = o
+	default:
+		msg = fmt.Sprintf("%#v", o)
+	}
+
+	return fmt.Sprintf("%s trace_id=%s", msg, traceID)
+}
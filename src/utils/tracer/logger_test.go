@@ -0,0 +1,68 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_tracedLogger(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		obj        any
+		wantPrefix string
+		wantSuffix string
+	}{
+		{
+			name:       "traced message",
+			ctx:        traced,
+			obj:        "task ran",
+			wantPrefix: "task ran",
+			wantSuffix: " trace_id=" + traceID.String(),
+		},
+		{
+			name:       "traced error keeps its origin",
+			ctx:        traced,
+			obj:        errors.NewWithCode(codes.CodeInternalServerError, "task failed"),
+			wantPrefix: "/",
+			wantSuffix: "--- task failed trace_id=" + traceID.String(),
+		},
+		{
+			name:       "context without a trace",
+			ctx:        context.Background(),
+			obj:        "task ran",
+			wantPrefix: "task ran",
+			wantSuffix: "task ran",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			got := ""
+			logMock := mock_log.NewMockInterface(ctrl)
+			logMock.EXPECT().Info(tt.ctx, gomock.Any()).Do(func(ctx context.Context, obj any) { got = fmt.Sprint(obj) })
+
+			WrapLogger(logMock).Info(tt.ctx, tt.obj)
+			if !strings.HasPrefix(got, tt.wantPrefix) || !strings.HasSuffix(got, tt.wantSuffix) {
+				t.Errorf("Info() message = %q, want it between %q and %q", got, tt.wantPrefix, tt.wantSuffix)
+			}
+		})
+	}
+}
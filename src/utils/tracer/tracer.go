@@ -0,0 +1,239 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterOTLP   string = "otlp"
+	ExporterStdout string = "stdout"
+	ExporterFile   string = "file"
+
+	instrumentationName string = "github.com/downsized-devs/template-service-go"
+)
+
+type Interface interface {
+	// Start creates a span and a context containing the newly-created span
+	Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span)
+	// Extract reads the incoming W3C trace context from the carrier
+	Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context
+	// Inject writes the current trace context into the outgoing carrier
+	Inject(ctx context.Context, carrier propagation.TextMapCarrier)
+	// Transport wraps an http.RoundTripper so every outbound call gets a client span
+	Transport(base http.RoundTripper) http.RoundTripper
+	// Stop flushes pending spans and closes the exporter
+	Stop(ctx context.Context)
+}
+
+// Config SampleRatio is the share of new traces recorded, traces continued from a caller follow
+// its decision. Leaving it out records every trace while 0 records none
+type Config struct {
+	Enabled     bool
+	ServiceName string
+	Exporter    string
+	SampleRatio *float64
+	OTLP        OTLPConfig
+	File        FileConfig
+}
+
+type OTLPConfig struct {
+	Endpoint string
+	URLPath  string
+	Insecure bool
+	Headers  map[string]string
+}
+
+type FileConfig struct {
+	Path string
+}
+
+type tracer struct {
+	log        logger.Interface
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	closer     io.Closer
+}
+
+func Init(cfg Config, log logger.Interface) Interface {
+	t := &tracer{
+		log:        log,
+		tracer:     trace.NewNoopTracerProvider().Tracer(instrumentationName),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+
+	if !cfg.Enabled {
+		return t
+	}
+
+	ctx := context.Background()
+	exporter, err := t.initExporter(ctx, cfg)
+	if err != nil {
+		log.Fatal(ctx, fmt.Sprintf("Initializing trace exporter error: %s", err.Error()))
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		log.Fatal(ctx, fmt.Sprintf("Initializing trace resource error: %s", err.Error()))
+	}
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(newSampler(cfg.SampleRatio))),
+	)
+	t.tracer = t.provider.Tracer(instrumentationName)
+
+	otel.SetTracerProvider(t.provider)
+	otel.SetTextMapPropagator(t.propagator)
+
+	return t
+}
+
+// newSampler samples every root trace when no ratio is set, an explicit 0 samples none
+func newSampler(ratio *float64) sdktrace.Sampler {
+	switch {
+	case ratio == nil || *ratio >= 1:
+		return sdktrace.AlwaysSample()
+	case *ratio <= 0:
+		return sdktrace.NeverSample()
+	default:
+		return sdktrace.TraceIDRatioBased(*ratio)
+	}
+}
+
+func (t *tracer) initExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cfg.OTLP.Endpoint),
+			otlptracehttp.WithHeaders(cfg.OTLP.Headers),
+		}
+		if cfg.OTLP.URLPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(cfg.OTLP.URLPath))
+		}
+		if cfg.OTLP.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterFile:
+		f, err := os.OpenFile(cfg.File.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		t.closer = f
+		return stdouttrace.New(stdouttrace.WithWriter(f))
+	case ExporterStdout, "":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, errors.NewWithCode(codes.CodeInvalidValue, "unknown trace exporter %s", cfg.Exporter)
+	}
+}
+
+func (t *tracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, spanName, opts...)
+}
+
+func (t *tracer) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return t.propagator.Extract(ctx, carrier)
+}
+
+func (t *tracer) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	t.propagator.Inject(ctx, carrier)
+}
+
+func (t *tracer) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{base: base, tracer: t}
+}
+
+func (t *tracer) Stop(ctx context.Context) {
+	if t.provider != nil {
+		if err := t.provider.Shutdown(ctx); err != nil {
+			t.log.Error(ctx, fmt.Sprintf("Shutting down tracer provider error: %s", err.Error()))
+		}
+	}
+
+	if t.closer != nil {
+		t.closer.Close()
+	}
+}
+
+// TraceID returns the hex trace id of the span stored in the context, or empty if there is none
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+
+	return sc.TraceID().String()
+}
+
+// SetStatus records the http status of a span and flags it as failed on server errors
+func SetStatus(span trace.Span, httpStatus int, err error) {
+	if httpStatus > 0 {
+		span.SetAttributes(semconv.HTTPStatusCode(httpStatus))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+		return
+	}
+
+	if httpStatus >= http.StatusInternalServerError {
+		span.SetStatus(otelcodes.Error, http.StatusText(httpStatus))
+	}
+}
+
+type transport struct {
+	base   http.RoundTripper
+	tracer *tracer
+}
+
+func (tr *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tr.tracer.Start(req.Context(), fmt.Sprintf("HTTP %s", req.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethod(req.Method),
+			semconv.HTTPURL(req.URL.String()),
+			semconv.NetPeerName(req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	tr.tracer.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := tr.base.RoundTrip(req)
+	if err != nil {
+		SetStatus(span, 0, err)
+		return resp, err
+	}
+
+	SetStatus(span, resp.StatusCode, nil)
+	return resp, nil
+}
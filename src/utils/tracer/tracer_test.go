@@ -0,0 +1,29 @@
+package tracer
+
+import (
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func Test_newSampler(t *testing.T) {
+	ratio := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name  string
+		ratio *float64
+		want  string
+	}{
+		{name: "unset", ratio: nil, want: sdktrace.AlwaysSample().Description()},
+		{name: "zero", ratio: ratio(0), want: sdktrace.NeverSample().Description()},
+		{name: "partial", ratio: ratio(0.25), want: sdktrace.TraceIDRatioBased(0.25).Description()},
+		{name: "every trace", ratio: ratio(1), want: sdktrace.AlwaysSample().Description()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newSampler(tt.ratio).Description(); got != tt.want {
+				t.Errorf("newSampler() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
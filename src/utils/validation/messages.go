@@ -0,0 +1,121 @@
+package validation
+
+import "github.com/downsized-devs/sdk-go/language"
+
+const (
+	ruleRequired string = "required"
+
+	kindString string = ".string"
+	kindItems  string = ".items"
+)
+
+// ruleAliases share the message of a rule reading the same to a client
+var ruleAliases = map[string]string{
+	"gte":          "min",
+	"lte":          "max",
+	"eqfield":      "eq",
+	"nefield":      "ne",
+	"uri":          "url",
+	"http_url":     "url",
+	"uuid4":        "uuid",
+	"uuid_rfc4122": "uuid",
+	"number":       "numeric",
+}
+
+var fallbackMessages = map[string]string{
+	language.English:    "{field} failed on the {rule} rule",
+	language.Indonesian: "{field} tidak memenuhi aturan {rule}",
+}
+
+// defaultMessages covers the built in rules used in binding tags, rule.string and rule.items
+// variants win over the plain rule for strings and for slices, arrays and maps
+var defaultMessages = map[string]map[string]string{
+	ruleRequired: {
+		language.English:    "{field} is required",
+		language.Indonesian: "{field} wajib diisi",
+	},
+	"email": {
+		language.English:    "{field} must be a valid email address",
+		language.Indonesian: "{field} harus berupa alamat email yang valid",
+	},
+	"url": {
+		language.English:    "{field} must be a valid URL",
+		language.Indonesian: "{field} harus berupa URL yang valid",
+	},
+	"uuid": {
+		language.English:    "{field} must be a valid UUID",
+		language.Indonesian: "{field} harus berupa UUID yang valid",
+	},
+	"oneof": {
+		language.English:    "{field} must be one of [{param}]",
+		language.Indonesian: "{field} harus salah satu dari [{param}]",
+	},
+	"numeric": {
+		language.English:    "{field} must be a number",
+		language.Indonesian: "{field} harus berupa angka",
+	},
+	"alpha": {
+		language.English:    "{field} may only contain letters",
+		language.Indonesian: "{field} hanya boleh berisi huruf",
+	},
+	"alphanum": {
+		language.English:    "{field} may only contain letters and numbers",
+		language.Indonesian: "{field} hanya boleh berisi huruf dan angka",
+	},
+	"datetime": {
+		language.English:    "{field} must be a date time in the {param} format",
+		language.Indonesian: "{field} harus berupa tanggal dan waktu dengan format {param}",
+	},
+	"eq": {
+		language.English:    "{field} must be equal to {param}",
+		language.Indonesian: "{field} harus sama dengan {param}",
+	},
+	"ne": {
+		language.English:    "{field} must not be equal to {param}",
+		language.Indonesian: "{field} tidak boleh sama dengan {param}",
+	},
+	"min": {
+		language.English:    "{field} must be {param} or greater",
+		language.Indonesian: "{field} minimal {param}",
+	},
+	"min" + kindString: {
+		language.English:    "{field} must be at least {param} characters long",
+		language.Indonesian: "{field} minimal {param} karakter",
+	},
+	"min" + kindItems: {
+		language.English:    "{field} must contain at least {param} items",
+		language.Indonesian: "{field} minimal berisi {param} item",
+	},
+	"max": {
+		language.English:    "{field} must be {param} or less",
+		language.Indonesian: "{field} maksimal {param}",
+	},
+	"max" + kindString: {
+		language.English:    "{field} must be at most {param} characters long",
+		language.Indonesian: "{field} maksimal {param} karakter",
+	},
+	"max" + kindItems: {
+		language.English:    "{field} must contain at most {param} items",
+		language.Indonesian: "{field} maksimal berisi {param} item",
+	},
+	"len": {
+		language.English:    "{field} must be {param}",
+		language.Indonesian: "{field} harus {param}",
+	},
+	"len" + kindString: {
+		language.English:    "{field} must be {param} characters long",
+		language.Indonesian: "{field} harus {param} karakter",
+	},
+	"len" + kindItems: {
+		language.English:    "{field} must contain {param} items",
+		language.Indonesian: "{field} harus berisi {param} item",
+	},
+	"gt": {
+		language.English:    "{field} must be greater than {param}",
+		language.Indonesian: "{field} harus lebih besar dari {param}",
+	},
+	"lt": {
+		language.English:    "{field} must be less than {param}",
+		language.Indonesian: "{field} harus lebih kecil dari {param}",
+	},
+}
@@ -0,0 +1,208 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/language"
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/downsized-devs/template-service-go/src/utils/fielderror"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	TagJSON string = "json"
+	TagForm string = "form"
+	TagURI  string = "uri"
+
+	placeholderField string = "{field}"
+	placeholderParam string = "{param}"
+	placeholderRule  string = "{rule}"
+)
+
+type Interface interface {
+	// Register adds a custom rule to the binding tags, rules must be registered before the server starts
+	Register(rule Rule) error
+	// Translate lists every failed rule of a validation error in lang, naming the fields of obj by
+	// the given struct tag. ok is false when err is not a validation error
+	Translate(err error, obj interface{}, tag string, lang string) (fieldErr error, ok bool)
+}
+
+// Rule is a custom validation rule. Messages are keyed by language with English as the fallback
+// and may use the {field}, {param} and {rule} placeholders
+type Rule struct {
+	Tag        string
+	Func       validator.Func
+	CallIfNull bool
+	Messages   map[string]string
+}
+
+type validation struct {
+	log    logger.Interface
+	engine *validator.Validate
+
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+}
+
+// Init wraps the validator engine used by the request binding, usually the gin one
+func Init(log logger.Interface, engine *validator.Validate) Interface {
+	v := &validation{
+		log:      log,
+		engine:   engine,
+		messages: map[string]map[string]string{},
+	}
+
+	for rule, msgs := range defaultMessages {
+		v.messages[rule] = msgs
+	}
+
+	return v
+}
+
+func (v *validation) Register(rule Rule) error {
+	if rule.Tag == "" || rule.Func == nil {
+		return errors.NewWithCode(codes.CodeInvalidValue, "validation rule needs a tag and a func")
+	}
+
+	if err := v.engine.RegisterValidation(rule.Tag, rule.Func, rule.CallIfNull); err != nil {
+		return errors.NewWithCode(codes.CodeInvalidValue, "registering validation rule %s: %s", rule.Tag, err.Error())
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(rule.Messages) > 0 {
+		v.messages[rule.Tag] = rule.Messages
+	}
+
+	v.log.Debug(context.Background(), fmt.Sprintf("Validation rule %s registered", rule.Tag))
+
+	return nil
+}
+
+func (v *validation) Translate(err error, obj interface{}, tag string, lang string) (error, bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+
+	t := reflect.TypeOf(obj)
+	fields := make([]fielderror.Field, 0, len(verrs))
+	for _, fe := range verrs {
+		name := fieldPath(t, fe.StructNamespace(), tag)
+		fields = append(fields, fielderror.Field{
+			Field:   name,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: v.message(fe, name, lang),
+		})
+	}
+
+	return fielderror.New(fields), true
+}
+
+func (v *validation) message(fe validator.FieldError, name string, lang string) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	tmpl := fallbackMessages
+	for _, key := range messageKeys(fe) {
+		if msgs, ok := v.messages[key]; ok {
+			tmpl = msgs
+			break
+		}
+	}
+
+	msg, ok := tmpl[lang]
+	if !ok {
+		msg = tmpl[language.English]
+	}
+
+	return strings.NewReplacer(
+		placeholderField, name,
+		placeholderParam, fe.Param(),
+		placeholderRule, fe.Tag(),
+	).Replace(msg)
+}
+
+// messageKeys tries the message of the rule for the kind of value first, so min reads
+// as a length for strings, a count for lists and a bound for numbers
+func messageKeys(fe validator.FieldError) []string {
+	rule := fe.Tag()
+	if alias, ok := ruleAliases[rule]; ok {
+		rule = alias
+	} else if strings.HasPrefix(rule, ruleRequired+"_") {
+		rule = ruleRequired
+	}
+
+	switch fe.Kind() {
+	case reflect.String:
+		return []string{rule + kindString, rule}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return []string{rule + kindItems, rule}
+	default:
+		return []string{rule}
+	}
+}
+
+// fieldPath turns a struct namespace like Params.Items[0].Name into the names the client
+// sent, e.g. items[0].name, skipping the root type and untagged embedded structs
+func fieldPath(t reflect.Type, namespace string, tag string) string {
+	segments := strings.Split(namespace, ".")
+	if len(segments) > 1 {
+		segments = segments[1:]
+	}
+
+	path := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		goName, index := seg, ""
+		if i := strings.Index(seg, "["); i >= 0 {
+			goName, index = seg[:i], seg[i:]
+		}
+
+		t = structType(t)
+		if t == nil || t.Kind() != reflect.Struct {
+			path = append(path, seg)
+			t = nil
+			continue
+		}
+
+		sf, ok := t.FieldByName(goName)
+		if !ok {
+			path = append(path, seg)
+			t = nil
+			continue
+		}
+
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		t = sf.Type
+		for n := strings.Count(index, "["); n > 0; n-- {
+			t = structType(t).Elem()
+		}
+
+		switch {
+		case sf.Anonymous && name == "":
+			continue
+		case name == "" || name == "-":
+			name = sf.Name
+		}
+
+		path = append(path, name+index)
+	}
+
+	return strings.Join(path, ".")
+}
+
+func structType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
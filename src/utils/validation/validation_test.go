@@ -0,0 +1,208 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/language"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"github.com/downsized-devs/template-service-go/src/utils/fielderror"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/mock/gomock"
+)
+
+type testAddress struct {
+	City string `json:"city" form:"city" binding:"required"`
+}
+
+type testEmbedded struct {
+	Note string `json:"note" binding:"max=4"`
+}
+
+type testParams struct {
+	testEmbedded
+	Name    string        `json:"name" form:"name_q" binding:"required,min=3"`
+	Age     int           `json:"age" binding:"gte=18"`
+	Tags    []string      `json:"tags" binding:"max=2"`
+	Items   []testAddress `json:"items" binding:"dive"`
+	Code    string        `json:"code" binding:"omitempty,even"`
+	Country string        `binding:"oneof=ID SG"`
+}
+
+func newTestEngine() *validator.Validate {
+	engine := validator.New()
+	engine.SetTagName("binding")
+	return engine
+}
+
+func Test_validation_Translate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+	logMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+
+	engine := newTestEngine()
+	v := Init(logMock, engine)
+	if err := v.Register(Rule{
+		Tag:  "even",
+		Func: func(fl validator.FieldLevel) bool { return len(fl.Field().String())%2 == 0 },
+		Messages: map[string]string{
+			language.English:    "{field} must have an even length",
+			language.Indonesian: "panjang {field} harus genap",
+		},
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	valid := testParams{Name: "task", Age: 18, Country: "ID"}
+
+	tests := []struct {
+		name   string
+		params func(p *testParams)
+		tag    string
+		lang   string
+		want   []fielderror.Field
+	}{
+		{
+			name:   "string length in english",
+			params: func(p *testParams) { p.Name = "ab" },
+			tag:    TagJSON,
+			lang:   language.English,
+			want:   []fielderror.Field{{Field: "name", Rule: "min", Param: "3", Message: "name must be at least 3 characters long"}},
+		},
+		{
+			name:   "string length in indonesian",
+			params: func(p *testParams) { p.Name = "ab" },
+			tag:    TagJSON,
+			lang:   language.Indonesian,
+			want:   []fielderror.Field{{Field: "name", Rule: "min", Param: "3", Message: "name minimal 3 karakter"}},
+		},
+		{
+			name:   "unknown language falls back to english",
+			params: func(p *testParams) { p.Name = "" },
+			tag:    TagJSON,
+			lang:   "fr",
+			want:   []fielderror.Field{{Field: "name", Rule: "required", Message: "name is required"}},
+		},
+		{
+			name:   "named by the bound tag",
+			params: func(p *testParams) { p.Name = "" },
+			tag:    TagForm,
+			lang:   language.English,
+			want:   []fielderror.Field{{Field: "name_q", Rule: "required", Message: "name_q is required"}},
+		},
+		{
+			name:   "aliased number bound",
+			params: func(p *testParams) { p.Age = 17 },
+			tag:    TagJSON,
+			lang:   language.English,
+			want:   []fielderror.Field{{Field: "age", Rule: "gte", Param: "18", Message: "age must be 18 or greater"}},
+		},
+		{
+			name:   "item count",
+			params: func(p *testParams) { p.Tags = []string{"a", "b", "c"} },
+			tag:    TagJSON,
+			lang:   language.English,
+			want:   []fielderror.Field{{Field: "tags", Rule: "max", Param: "2", Message: "tags must contain at most 2 items"}},
+		},
+		{
+			name:   "nested list field",
+			params: func(p *testParams) { p.Items = []testAddress{{City: "Jakarta"}, {}} },
+			tag:    TagJSON,
+			lang:   language.English,
+			want:   []fielderror.Field{{Field: "items[1].city", Rule: "required", Message: "items[1].city is required"}},
+		},
+		{
+			name:   "embedded struct field",
+			params: func(p *testParams) { p.Note = "too long" },
+			tag:    TagJSON,
+			lang:   language.English,
+			want:   []fielderror.Field{{Field: "note", Rule: "max", Param: "4", Message: "note must be at most 4 characters long"}},
+		},
+		{
+			name:   "untagged field keeps its go name",
+			params: func(p *testParams) { p.Country = "MY" },
+			tag:    TagJSON,
+			lang:   language.English,
+			want:   []fielderror.Field{{Field: "Country", Rule: "oneof", Param: "ID SG", Message: "Country must be one of [ID SG]"}},
+		},
+		{
+			name:   "custom rule",
+			params: func(p *testParams) { p.Code = "abc" },
+			tag:    TagJSON,
+			lang:   language.Indonesian,
+			want:   []fielderror.Field{{Field: "code", Rule: "even", Message: "panjang code harus genap"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := valid
+			tt.params(&params)
+
+			got, ok := v.Translate(engine.Struct(params), params, tt.tag, tt.lang)
+			if !ok {
+				t.Fatalf("Translate() ok = false, want a validation error")
+			}
+
+			fe, ok := fielderror.As(got)
+			if !ok {
+				t.Fatalf("Translate() = %v, want a field error", got)
+			}
+			if !reflect.DeepEqual(fe.Fields, tt.want) {
+				t.Errorf("Translate() fields = %+v, want %+v", fe.Fields, tt.want)
+			}
+		})
+	}
+
+	t.Run("not a validation error", func(t *testing.T) {
+		if _, ok := v.Translate(fmt.Errorf("unexpected EOF"), valid, TagJSON, language.English); ok {
+			t.Errorf("Translate() ok = true, want false")
+		}
+	})
+}
+
+func Test_validation_Register(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logMock := mock_log.NewMockInterface(ctrl)
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		mockFunc func()
+		wantErr  bool
+	}{
+		{
+			name:     "rule without a tag",
+			rule:     Rule{Func: func(fl validator.FieldLevel) bool { return true }},
+			mockFunc: func() {},
+			wantErr:  true,
+		},
+		{
+			name:     "rule without a func",
+			rule:     Rule{Tag: "always"},
+			mockFunc: func() {},
+			wantErr:  true,
+		},
+		{
+			name: "valid rule",
+			rule: Rule{Tag: "always", Func: func(fl validator.FieldLevel) bool { return true }},
+			mockFunc: func() {
+				logMock.EXPECT().Debug(gomock.Any(), gomock.Any())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFunc()
+
+			v := Init(logMock, newTestEngine())
+			if err := v.Register(tt.rule); (err != nil) != tt.wantErr {
+				t.Errorf("Register() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}